@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/qtopie/homa/gen/assistant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminServiceServerImpl implements the PluginAdminService on top of the
+// PluginManager and the copilot service's active plugin.
+type AdminServiceServerImpl struct {
+	assistant.UnimplementedPluginAdminServiceServer
+	pluginManager *PluginManager
+	copilot       *CopilotServiceServerImpl
+}
+
+// NewAdminServiceServerImpl creates a new instance of AdminServiceServerImpl
+func NewAdminServiceServerImpl(pluginManager *PluginManager, copilot *CopilotServiceServerImpl) *AdminServiceServerImpl {
+	return &AdminServiceServerImpl{
+		pluginManager: pluginManager,
+		copilot:       copilot,
+	}
+}
+
+// ListPlugins reports the plugin catalogue
+func (s *AdminServiceServerImpl) ListPlugins(ctx context.Context, req *assistant.ListPluginsRequest) (*assistant.ListPluginsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	catalogue, err := s.pluginManager.Catalogue()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to scan plugins: %v", err)
+	}
+
+	resp := &assistant.ListPluginsResponse{}
+	for _, info := range catalogue {
+		if req.Category != "" && info.Category != req.Category {
+			continue
+		}
+		resp.Plugins = append(resp.Plugins, s.pluginStatus(info))
+	}
+	return resp, nil
+}
+
+// LoadPlugin loads and initializes a plugin without activating it
+func (s *AdminServiceServerImpl) LoadPlugin(ctx context.Context, req *assistant.PluginRef) (*assistant.PluginStatus, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	category, err := pluginRef(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pluginManager.LoadPlugin(category, req.Name); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to load plugin %s/%s: %v", category, req.Name, err)
+	}
+	log.Printf("Admin loaded plugin %s/%s", category, req.Name)
+	return s.lookup(category, req.Name)
+}
+
+// ActivatePlugin makes a copilot plugin the active one
+func (s *AdminServiceServerImpl) ActivatePlugin(ctx context.Context, req *assistant.PluginRef) (*assistant.PluginStatus, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	category, err := pluginRef(req)
+	if err != nil {
+		return nil, err
+	}
+	if category != "copilot" {
+		return nil, status.Errorf(codes.InvalidArgument, "plugins of category %s cannot be activated", category)
+	}
+	if err := s.copilot.ActivatePlugin(req.Name); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to activate plugin %s: %v", req.Name, err)
+	}
+	log.Printf("Admin activated copilot plugin %s", req.Name)
+	return s.lookup(category, req.Name)
+}
+
+// PluginHealth probes a loaded plugin's backend
+func (s *AdminServiceServerImpl) PluginHealth(ctx context.Context, req *assistant.PluginRef) (*assistant.PluginHealthResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	category, err := pluginRef(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := s.pluginManager.getLoaded(category, req.Name); !exists {
+		return nil, status.Errorf(codes.NotFound, "plugin %s/%s is not loaded", category, req.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pluginHealthTimeout)
+	defer cancel()
+	resp := &assistant.PluginHealthResponse{Healthy: true}
+	if err := s.pluginManager.HealthCheck(ctx, category, req.Name); err != nil {
+		resp.Healthy, resp.Error = false, err.Error()
+	}
+	if resp.Plugin, err = s.lookup(category, req.Name); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReloadPlugin re-reads a loaded plugin's file. The active copilot plugin is
+// swapped the same way as on a hot reload, draining in-flight requests.
+func (s *AdminServiceServerImpl) ReloadPlugin(ctx context.Context, req *assistant.PluginRef) (*assistant.PluginStatus, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	category, err := pluginRef(req)
+	if err != nil {
+		return nil, err
+	}
+	if category == "copilot" && req.Name == s.copilot.currentName() {
+		err = s.copilot.ReloadPlugin()
+	} else {
+		err = s.pluginManager.ReloadPlugin(category, req.Name)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to reload plugin %s/%s: %v", category, req.Name, err)
+	}
+	log.Printf("Admin reloaded plugin %s/%s", category, req.Name)
+	return s.lookup(category, req.Name)
+}
+
+// pluginRef validates a plugin reference and returns its category
+func pluginRef(req *assistant.PluginRef) (string, error) {
+	if req.Name == "" {
+		return "", status.Error(codes.InvalidArgument, "plugin name is required")
+	}
+	if req.Category == "" {
+		return "copilot", nil
+	}
+	return req.Category, nil
+}
+
+// lookup returns the catalogue entry of a single plugin
+func (s *AdminServiceServerImpl) lookup(category, pluginName string) (*assistant.PluginStatus, error) {
+	catalogue, err := s.pluginManager.Catalogue()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to scan plugins: %v", err)
+	}
+	for _, info := range catalogue {
+		if info.Category == category && info.Name == pluginName {
+			return s.pluginStatus(info), nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "plugin %s/%s not found", category, pluginName)
+}
+
+func (s *AdminServiceServerImpl) pluginStatus(info PluginInfo) *assistant.PluginStatus {
+	ps := &assistant.PluginStatus{
+		Category: info.Category,
+		Name:     info.Name,
+		Mode:     info.Mode,
+		Status:   info.Status,
+		Active:   info.Category == "copilot" && info.Name == s.copilot.currentName(),
+		Error:    info.Error,
+	}
+	if info.Manifest != nil {
+		ps.Version = info.Manifest.Version
+		ps.Capabilities = info.Manifest.Capabilities
+	}
+	if loaded, exists := s.pluginManager.getLoaded(info.Category, info.Name); exists {
+		ps.LoadedAt = loaded.LoadedAt.Format(time.RFC3339)
+	}
+	return ps
+}
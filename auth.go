@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyHeader carries an API token for clients that cannot send a bearer
+// token.
+const apiKeyHeader = "x-api-key"
+
+// serverCredentials returns the TLS option for the server from the [tls]
+// section, or nil when no certificate is configured. With client-ca-file set,
+// clients must present a certificate signed by it, unless client-auth is
+// optional.
+func serverCredentials() (grpc.ServerOption, error) {
+	conf := shared.PluginConfig(cfg.GetAppConfig().GetStringMap("tls"))
+	certFile, keyFile := conf.GetString("cert-file"), conf.GetString("key-file")
+	if certFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if caFile := conf.GetString("client-ca-file"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in client CA file %s", caFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if conf.GetString("client-auth") == "optional" {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return grpc.Creds(credentials.NewTLS(tlsConfig)), nil
+}
+
+// authEnabled reports whether requests must be authenticated.
+func authEnabled() bool {
+	return cfg.GetAppConfig().GetBool("auth.enabled")
+}
+
+// authenticator identifies the caller of every request, by a bearer token or
+// API key, or by its verified client certificate. Tokens are looked up in the
+// [auth.user.<name>] sections of the config, then in etcd.
+type authenticator struct {
+	tokens *auth.EtcdStore
+}
+
+func newAuthenticator() *authenticator {
+	endpoints := cfg.GetAppConfig().GetStringSlice("etcd.endpoints")
+	tokens, err := auth.NewEtcdStore(endpoints)
+	if err != nil {
+		log.Printf("failed to create etcd token store: %v", err)
+		tokens = nil
+	}
+	return &authenticator{tokens: tokens}
+}
+
+// authenticate returns ctx carrying the caller's principal. Without
+// [auth] enabled, requests pass anonymously.
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	if !authEnabled() {
+		return ctx, nil
+	}
+	p, err := a.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid credentials")
+	}
+	// Names from tokens and certificates are compared alike
+	p.Name = auth.NormalizeName(p.Name)
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+// principal identifies the caller, or returns nil if it cannot. A token that
+// is presented must be valid, even if the client also has a certificate.
+func (a *authenticator) principal(ctx context.Context) (*auth.Principal, error) {
+	token := requestToken(ctx)
+	if token == "" {
+		return certPrincipal(ctx), nil
+	}
+	if p := configPrincipal(token); p != nil {
+		return p, nil
+	}
+	if a.tokens == nil {
+		return nil, nil
+	}
+	p, err := a.tokens.Lookup(ctx, token)
+	if err != nil {
+		log.Printf("failed to look up token: %v", err)
+		return nil, status.Error(codes.Unavailable, "failed to check credentials")
+	}
+	return p, nil
+}
+
+// requestToken returns the request's bearer token or API key.
+func requestToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		scheme, token, found := strings.Cut(v[0], " ")
+		if found && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if v := md.Get(apiKeyHeader); len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
+
+// configPrincipal finds the [auth.user.<name>] section whose token, or
+// token-sha256, matches.
+func configPrincipal(token string) *auth.Principal {
+	hash := auth.HashToken(token)
+	for name, section := range cfg.GetAppConfig().GetStringMap("auth.user") {
+		values, ok := section.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conf := shared.PluginConfig(values)
+		want := strings.ToLower(conf.GetString("token-sha256"))
+		if t := conf.GetString("token"); want == "" && t != "" {
+			want = auth.HashToken(t)
+		}
+		if want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(hash)) == 1 {
+			return &auth.Principal{Name: name, Admin: conf.GetBool("admin", false)}
+		}
+	}
+	return nil
+}
+
+// certPrincipal names the caller by the common name of its verified client
+// certificate; admin is set in the [auth.user.<name>] section of that name.
+func certPrincipal(ctx context.Context) *auth.Principal {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	name := info.State.VerifiedChains[0][0].Subject.CommonName
+	if name == "" {
+		return nil
+	}
+	conf := shared.PluginConfig(cfg.GetAppConfig().GetStringMap("auth.user." + auth.NormalizeName(name)))
+	return &auth.Principal{Name: name, Admin: conf.GetBool("admin", false)}
+}
+
+// unaryInterceptor authenticates unary requests.
+func (a *authenticator) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor authenticates streams.
+func (a *authenticator) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream is a stream whose context carries the caller.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
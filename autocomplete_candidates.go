@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/qtopie/homa/gen/assistant"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+// maxCompletionCandidates caps the candidates a client may ask for.
+const maxCompletionCandidates = 8
+
+// wantedCandidates returns the number of candidates a request asks for.
+func wantedCandidates(req *assistant.UserRequest) int {
+	return min(max(int(req.MaxCandidates), 1), maxCompletionCandidates)
+}
+
+// rankCandidates drops empty and duplicate candidates, orders the rest by
+// score, keeping the plugin's order among equal scores, and keeps the best n.
+func rankCandidates(candidates []shared.Candidate, n int) []shared.Candidate {
+	ranked := make([]shared.Candidate, 0, len(candidates))
+	seen := make(map[string]int)
+	for _, c := range candidates {
+		if strings.TrimSpace(c.Content) == "" {
+			continue
+		}
+		if i, dup := seen[c.Content]; dup {
+			ranked[i].Score = max(ranked[i].Score, c.Score)
+			continue
+		}
+		seen[c.Content] = len(ranked)
+		ranked = append(ranked, c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// completionResponse builds the reply to an autocomplete request. Content is
+// the best candidate, for clients that predate candidates.
+func completionResponse(req *assistant.UserRequest, candidates []shared.Candidate) *assistant.AgentResponse {
+	resp := &assistant.AgentResponse{SessionId: req.SessionId, Seq: req.Seq}
+	for _, c := range candidates {
+		kind := assistant.CompletionKind_COMPLETION_KIND_SINGLE_LINE
+		if strings.Contains(c.Content, "\n") {
+			kind = assistant.CompletionKind_COMPLETION_KIND_MULTI_LINE
+		}
+		resp.Candidates = append(resp.Candidates, &assistant.CompletionCandidate{
+			Content: c.Content,
+			Range:   &assistant.Range{Start: -int32(c.ReplaceBefore), End: int32(c.ReplaceAfter)},
+			Score:   c.Score,
+			Kind:    kind,
+		})
+	}
+	if len(candidates) > 0 {
+		resp.Content = candidates[0].Content
+	}
+	return resp
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errSuperseded is the cancellation cause of a completion replaced by a newer
+// request of the same session.
+var errSuperseded = errors.New("superseded by a newer completion request")
+
+// completionTracker tracks the in-flight autocomplete request of each session,
+// so that a request with a newer Seq cancels the one the user typed past.
+type completionTracker struct {
+	mu       sync.Mutex
+	inflight map[string]*inflightCompletion
+
+	// delay is the [autocomplete] debounce, read when the config is loaded
+	delay atomic.Int64
+}
+
+type inflightCompletion struct {
+	seq    int32
+	cancel context.CancelCauseFunc
+}
+
+func newCompletionTracker() *completionTracker {
+	t := &completionTracker{inflight: make(map[string]*inflightCompletion)}
+	t.reload()
+	return t
+}
+
+// reload reads the debounce delay from the [autocomplete] section.
+func (t *completionTracker) reload() {
+	var delay time.Duration
+	if value := cfg.GetAppConfig().GetString("autocomplete.debounce"); value != "" {
+		var err error
+		if delay, err = time.ParseDuration(value); err != nil {
+			log.Printf("Ignoring autocomplete.debounce %q: %v", value, err)
+		}
+	}
+	t.delay.Store(int64(max(delay, 0)))
+}
+
+// begin registers a completion request and cancels the session's older one.
+// A request whose Seq is not newer than the one in flight is refused and
+// leaves it running; requests without a Seq supersede by arrival. The returned context is
+// cancelled with errSuperseded when a newer request arrives; done must be
+// called when the request finishes. Requests without a session are not
+// tracked.
+func (t *completionTracker) begin(ctx context.Context, sessionID string, seq int32) (context.Context, func(), error) {
+	if sessionID == "" {
+		return ctx, func() {}, nil
+	}
+	// Sessions are tracked per caller, so that nobody can supersede another
+	// user's completions
+	key := auth.Name(ctx) + "\x00" + sessionID
+	ctx, cancel := context.WithCancelCause(ctx)
+	entry := &inflightCompletion{seq: seq, cancel: cancel}
+
+	t.mu.Lock()
+	if current, ok := t.inflight[key]; ok {
+		if seq != 0 && seq <= current.seq {
+			t.mu.Unlock()
+			cancel(nil)
+			return nil, nil, status.Errorf(codes.Aborted, "completion %d is not newer than %d in flight", seq, current.seq)
+		}
+		current.cancel(errSuperseded)
+	}
+	t.inflight[key] = entry
+	t.mu.Unlock()
+
+	done := func() {
+		t.mu.Lock()
+		if t.inflight[key] == entry {
+			delete(t.inflight, key)
+		}
+		t.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, done, nil
+}
+
+// debounce waits for the [autocomplete] debounce delay before a plugin is
+// called, so a request superseded while the user is still typing never
+// reaches the model.
+func (t *completionTracker) debounce(ctx context.Context) error {
+	delay := time.Duration(t.delay.Load())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return supersededError(ctx)
+	}
+}
+
+// supersededError converts the error of a cancelled completion into the
+// status returned to the client: Aborted if a newer request replaced it.
+func supersededError(ctx context.Context) error {
+	if errors.Is(context.Cause(ctx), errSuperseded) {
+		return status.Error(codes.Aborted, errSuperseded.Error())
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
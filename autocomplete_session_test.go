@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qtopie/homa/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCompletionTrackerBegin(t *testing.T) {
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice"})
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob"})
+
+	tests := []struct {
+		name       string
+		firstCtx   context.Context
+		firstID    string
+		firstSeq   int32
+		secondCtx  context.Context
+		secondID   string
+		secondSeq  int32
+		superseded bool // the first request is cancelled
+		refused    bool // the second request fails with Aborted
+	}{
+		{"newer seq supersedes", alice, "s1", 1, alice, "s1", 2, true, false},
+		{"older seq is refused", alice, "s1", 2, alice, "s1", 1, false, true},
+		{"same seq is refused", alice, "s1", 3, alice, "s1", 3, false, true},
+		{"no seq supersedes by arrival", alice, "s1", 0, alice, "s1", 0, true, false},
+		{"no seq supersedes a numbered request", alice, "s1", 4, alice, "s1", 0, true, false},
+		{"other session runs alongside", alice, "s1", 5, alice, "s2", 1, false, false},
+		{"other principal runs alongside", alice, "s1", 5, bob, "s1", 1, false, false},
+		{"untracked without a session", alice, "", 5, alice, "", 1, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newCompletionTracker()
+			firstCtx, firstDone, err := tracker.begin(tt.firstCtx, tt.firstID, tt.firstSeq)
+			if err != nil {
+				t.Fatalf("first begin: %v", err)
+			}
+			defer firstDone()
+
+			secondCtx, secondDone, err := tracker.begin(tt.secondCtx, tt.secondID, tt.secondSeq)
+			if tt.refused {
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("second begin err = %v, want Aborted", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("second begin: %v", err)
+				}
+				defer secondDone()
+				if secondCtx.Err() != nil {
+					t.Errorf("second request cancelled: %v", secondCtx.Err())
+				}
+			}
+
+			if superseded := firstCtx.Err() != nil; superseded != tt.superseded {
+				t.Fatalf("first request superseded = %v, want %v", superseded, tt.superseded)
+			}
+			if tt.superseded {
+				if !errors.Is(context.Cause(firstCtx), errSuperseded) {
+					t.Errorf("cause = %v, want errSuperseded", context.Cause(firstCtx))
+				}
+				if code := status.Code(supersededError(firstCtx)); code != codes.Aborted {
+					t.Errorf("supersededError code = %v, want Aborted", code)
+				}
+			}
+		})
+	}
+}
+
+func TestCompletionTrackerDoneForgetsRequest(t *testing.T) {
+	tracker := newCompletionTracker()
+	_, done, err := tracker.begin(context.Background(), "s1", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+
+	// Once the request finished, a request with a lower seq may run
+	_, done, err = tracker.begin(context.Background(), "s1", 1)
+	if err != nil {
+		t.Fatalf("begin after done: %v", err)
+	}
+	done()
+}
+
+func TestCompletionTrackerDebounce(t *testing.T) {
+	setConfig(t, "autocomplete.debounce", "20ms")
+	tracker := newCompletionTracker()
+
+	start := time.Now()
+	if err := tracker.debounce(context.Background()); err != nil {
+		t.Fatalf("debounce: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("debounce returned after %s", elapsed)
+	}
+
+	// A superseded request stops waiting
+	setConfig(t, "autocomplete.debounce", "1h")
+	tracker.reload()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errSuperseded)
+	if err := tracker.debounce(ctx); status.Code(err) != codes.Aborted {
+		t.Errorf("debounce err = %v, want Aborted", err)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/qtopie/homa/gen/assistant"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/auth"
+)
+
+// AutoCompleteStream implements the server streaming completion RPC. It
+// behaves like AutoComplete, but sends the completion in parts as the plugin
+// generates it; plugins without streaming completion send it in one part.
+// Like Chat, it only falls back to the next plugin before anything was sent.
+func (s *CopilotServiceServerImpl) AutoCompleteStream(req *assistant.UserRequest, stream assistant.CopilotService_AutoCompleteStreamServer) (err error) {
+	ctx := stream.Context()
+	sink := &responseSink{stream: stream, req: shared.UserRequest{SessionId: req.SessionId, Seq: req.Seq}}
+	defer func() {
+		if err != nil {
+			sink.fail(err)
+		}
+	}()
+
+	persist, err := s.bindSession(ctx, req.SessionId, req.Workspace)
+	if err != nil {
+		return err
+	}
+	scopes := requestScopes(ctx, req.SessionId, req.Workspace, persist)
+	plugins, err := s.budgetedPlugins(ctx, rpcAutoComplete, req, scopes)
+	if err != nil {
+		return err
+	}
+
+	// Supersede the session's previous completion
+	callCtx, done, err := s.inflight.begin(ctx, req.SessionId, req.Seq)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	principal := auth.Name(ctx)
+	cacheReq := shared.UserRequest{FrontPart: req.FrontPart, BackPart: req.BackPart, Filename: req.Filename, Workspace: req.Workspace}
+	if candidates, name, ok := s.completions.get(principal, plugins[0], cacheReq, 1); ok {
+		if err := sink.send(name, shared.ChunkData{Content: candidates[0].Content}); err != nil {
+			return err
+		}
+		return sink.finish(name)
+	}
+
+	// Wait for typing to pause
+	if err := s.inflight.debounce(callCtx); err != nil {
+		return err
+	}
+
+	// Load session history and persist user message
+	var hist []shared.Message
+	if persist {
+		hist = s.loadHistory(ctx, req.SessionId, req.Message)
+	}
+	pluginReq := shared.UserRequest{
+		SessionId: req.SessionId,
+		Seq:       req.Seq,
+		Message:   req.Message,
+		FrontPart: req.FrontPart,
+		BackPart:  req.BackPart,
+		Filename:  req.Filename,
+		Workspace: req.Workspace,
+		History:   hist,
+	}
+
+	var reply, name string
+	acct := requestUsage{scopes: scopes}
+	defer s.recordRequest(pluginReq, &acct)
+	for i, plugin := range plugins {
+		if i > 0 {
+			log.Printf("Falling back to copilot plugin %s: %v", plugin, err)
+		}
+
+		var sent bool
+		name = plugin
+		reply, sent, err = s.streamWith(callCtx, plugin, "AutoCompleteStream", sink.send, &acct, func(ctx context.Context, p CopilotPlugin) (<-chan shared.ChunkData, error) {
+			return autoCompleteStream(ctx, p, pluginReq)
+		})
+		if err == nil || sent || !retryable(callCtx, err) {
+			break
+		}
+	}
+	if err != nil {
+		if callCtx.Err() != nil && ctx.Err() == nil {
+			return supersededError(callCtx)
+		}
+		return err
+	}
+	s.completions.put(principal, plugins[0], cacheReq, 1, rankCandidates([]shared.Candidate{{Content: reply}}, 1), name)
+
+	// Persist assistant reply
+	if persist {
+		s.saveReply(ctx, req.SessionId, reply)
+	}
+	return sink.finish(name)
+}
+
+// autoCompleteStream streams a plugin's completion, or sends its unary
+// completion as a single chunk if it cannot stream.
+func autoCompleteStream(ctx context.Context, p CopilotPlugin, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	if completer, ok := p.(shared.StreamingCompleter); ok {
+		return completer.AutoCompleteStream(ctx, req)
+	}
+
+	reply, err := p.AutoComplete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan shared.ChunkData, 1)
+	ch <- shared.ChunkData{Content: reply, IsLast: true}
+	close(ch)
+	return ch, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/qtopie/homa/gen/assistant"
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/auth"
+	"github.com/qtopie/homa/internal/budget"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// What a budget is kept for
+const (
+	budgetGlobal    = "global"
+	budgetUser      = "user"
+	budgetWorkspace = "workspace"
+	budgetSession   = "session"
+)
+
+// What happens to requests once a budget is spent
+const (
+	budgetReject = "reject"
+	budgetRoute  = "route"
+)
+
+// budgetCheckTimeout bounds reading a request's budgets; budgets that cannot
+// be read in time are not enforced.
+const budgetCheckTimeout = 2 * time.Second
+
+// budgetRule limits the requests and tokens spent over a rolling window,
+// globally or for each caller, workspace or session. Rules are read from
+// [budget.<name>] sections.
+type budgetRule struct {
+	name     string
+	scope    string
+	window   time.Duration
+	requests int64 // 0 means unlimited
+	tokens   int64 // 0 means unlimited
+	action   string
+	plugin   string // where requests go once spent, for the route action
+}
+
+// budgetRules reads the budgets from the config.
+func budgetRules() []budgetRule {
+	sections := cfg.GetAppConfig().GetStringMap("budget")
+	rules := make([]budgetRule, 0, len(sections))
+	for name, section := range sections {
+		values, ok := section.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conf := shared.PluginConfig(values)
+		rule := budgetRule{
+			name:     name,
+			scope:    conf.GetString("scope"),
+			window:   conf.GetDuration("window", 24*time.Hour),
+			requests: int64(conf.GetInt("requests", 0)),
+			tokens:   int64(conf.GetInt("tokens", 0)),
+			action:   conf.GetString("action"),
+			plugin:   conf.GetString("plugin"),
+		}
+		if rule.scope == "" {
+			rule.scope = budgetGlobal
+		}
+		if rule.action == "" {
+			rule.action = budgetReject
+		}
+
+		switch {
+		case rule.scope != budgetGlobal && rule.scope != budgetUser && rule.scope != budgetWorkspace && rule.scope != budgetSession:
+			log.Printf("Ignoring budget %s: unknown scope %q", name, rule.scope)
+		case rule.action != budgetReject && rule.action != budgetRoute:
+			log.Printf("Ignoring budget %s: unknown action %q", name, rule.action)
+		case rule.action == budgetRoute && rule.plugin == "":
+			log.Printf("Ignoring budget %s: no plugin to route to", name)
+		case rule.window <= 0:
+			log.Printf("Ignoring budget %s: invalid window", name)
+		case rule.requests <= 0 && rule.tokens <= 0:
+			log.Printf("Ignoring budget %s: no requests or tokens limit", name)
+		default:
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].name < rules[j].name })
+	return rules
+}
+
+// budgetScopes are who and what a request's spending is counted for.
+type budgetScopes struct {
+	user      string // the caller's principal name
+	workspace string
+	session   string
+}
+
+// requestScopes returns the scopes of a request. Its workspace and session
+// only count once its session is bound, as bindSession reports with bound.
+// Clients still choose those names when they bind a new session, so only
+// user budgets hold against a client that wants a fresh budget.
+func requestScopes(ctx context.Context, sessionID, workspace string, bound bool) budgetScopes {
+	scopes := budgetScopes{user: auth.Name(ctx)}
+	if bound {
+		scopes.workspace, scopes.session = workspace, sessionID
+	}
+	return scopes
+}
+
+// scopeKey names the caller, workspace or session a request's spending is
+// counted for. ok is false for workspace and session budgets of requests
+// without a bound session, which they do not apply to; anonymous callers
+// share one user budget.
+func (b *budgetRule) scopeKey(scopes budgetScopes) (key string, ok bool) {
+	switch b.scope {
+	case budgetUser:
+		return scopes.user, true
+	case budgetWorkspace:
+		return scopes.workspace, scopes.workspace != ""
+	case budgetSession:
+		return scopes.session, scopes.session != ""
+	}
+	return "", true
+}
+
+func (b *budgetRule) spent(s budget.Spent) bool {
+	return (b.requests > 0 && s.Requests >= b.requests) || (b.tokens > 0 && s.Tokens >= b.tokens)
+}
+
+// budgetedPlugins lists the plugins to try for a request, like
+// candidatePlugins, after checking the request's budgets. A spent budget
+// fails the request with ResourceExhausted, or sends it to the budget's
+// plugin without fallback; rejecting budgets win over routing ones, and
+// requests pinned by a strict route are rejected rather than rerouted.
+func (s *CopilotServiceServerImpl) budgetedPlugins(ctx context.Context, rpc string, req *assistant.UserRequest, scopes budgetScopes) ([]string, error) {
+	plugins := s.candidatePlugins(rpc, req)
+	store := s.budgetStore
+	if store == nil {
+		return plugins, nil
+	}
+	rules := budgetRules()
+	if len(rules) == 0 {
+		return plugins, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, budgetCheckTimeout)
+	defer cancel()
+	now := time.Now()
+	_, strict := routePlugin(rpc, req)
+	var route *budgetRule
+	for i := range rules {
+		b := &rules[i]
+		key, ok := b.scopeKey(scopes)
+		if !ok {
+			continue
+		}
+		spent, err := store.Spent(ctx, b.name, key, b.window, now)
+		if err != nil {
+			log.Printf("failed to check budget %s: %v", b.name, err)
+			continue
+		}
+		if !b.spent(spent) {
+			continue
+		}
+		if b.action == budgetReject || strict {
+			return nil, status.Errorf(codes.ResourceExhausted, "budget %s is spent: %d requests and %d tokens in the last %s",
+				b.name, spent.Requests, spent.Tokens, b.window)
+		}
+		if route == nil {
+			route = b
+		}
+	}
+	if route != nil {
+		log.Printf("Budget %s is spent, routing to copilot plugin %s", route.name, route.plugin)
+		return []string{route.plugin}, nil
+	}
+	return plugins, nil
+}
+
+// chargeBudgets charges a request and its tokens to every budget.
+func chargeBudgets(ctx context.Context, store *budget.EtcdStore, t time.Time, scopes budgetScopes, u shared.Usage) {
+	tokens := u.TotalTokens
+	if tokens == 0 {
+		tokens = u.PromptTokens + u.CompletionTokens
+	}
+	for _, b := range budgetRules() {
+		key, ok := b.scopeKey(scopes)
+		if !ok {
+			continue
+		}
+		if err := store.Add(ctx, b.name, key, b.window, t, int64(tokens)); err != nil {
+			log.Printf("failed to charge budget %s: %v", b.name, err)
+		}
+	}
+}
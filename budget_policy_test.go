@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qtopie/homa/internal/auth"
+)
+
+func TestBudgetScopeKey(t *testing.T) {
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice"})
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		bound bool
+		scope string
+		want  string
+		ok    bool
+	}{
+		{"global", alice, true, budgetGlobal, "", true},
+		{"user", alice, false, budgetUser, "alice", true},
+		{"anonymous user", context.Background(), true, budgetUser, "", true},
+		{"bound workspace", alice, true, budgetWorkspace, "/work/app", true},
+		{"unbound workspace", alice, false, budgetWorkspace, "", false},
+		{"bound session", alice, true, budgetSession, "s1", true},
+		{"unbound session", alice, false, budgetSession, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := budgetRule{scope: tt.scope}
+			scopes := requestScopes(tt.ctx, "s1", "/work/app", tt.bound)
+			if got, ok := rule.scopeKey(scopes); got != tt.want || ok != tt.ok {
+				t.Errorf("scopeKey() = %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/qtopie/homa/gen/assistant"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ChatSession implements the bidirectional chat RPC. The stream stays open
+// for the whole session: each turn runs like a Chat request, in the
+// background so that the client can cancel it or answer its tool calls while
+// tokens are streamed back.
+func (s *CopilotServiceServerImpl) ChatSession(stream assistant.CopilotService_ChatSessionServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	start := first.GetStart()
+	if start == nil || start.SessionId == "" {
+		return status.Error(codes.InvalidArgument, "a chat session must begin with a start request naming the session")
+	}
+
+	if _, err := s.bindSession(stream.Context(), start.SessionId, start.Workspace); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	c := &chatSession{
+		s:         s,
+		stream:    stream,
+		id:        start.SessionId,
+		workspace: start.Workspace,
+		settings:  start.GetSettings(),
+		approvals: make(map[string]chan bool),
+	}
+	// Wait for the running turn before the stream is closed
+	defer c.turns.Wait()
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// The client has nothing more to send but still reads the turn;
+			// its tool calls can no longer be approved
+			c.denyApprovals()
+			return nil
+		}
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		switch r := req.Request.(type) {
+		case *assistant.SessionRequest_Turn:
+			c.startTurn(ctx, r.Turn)
+		case *assistant.SessionRequest_Cancel:
+			c.cancelTurn(r.Cancel.Seq)
+		case *assistant.SessionRequest_ToolDecision:
+			c.decide(r.ToolDecision)
+		case *assistant.SessionRequest_Settings:
+			c.mu.Lock()
+			c.settings = r.Settings
+			c.mu.Unlock()
+		case *assistant.SessionRequest_Start:
+			c.sendError(0, "the session has already started")
+		default:
+			c.sendError(0, "empty session request")
+		}
+	}
+}
+
+// chatSession is the state of one ChatSession stream.
+type chatSession struct {
+	s         *CopilotServiceServerImpl
+	stream    assistant.CopilotService_ChatSessionServer
+	id        string
+	workspace string
+
+	// sendMu serializes sends from the receive loop, the turn and its tool
+	// calls
+	sendMu sync.Mutex
+
+	mu        sync.Mutex
+	settings  *assistant.SessionSettings
+	turn      *sessionTurn // the running turn, or nil
+	nextCall  int
+	approvals map[string]chan bool // tool calls waiting for a decision
+	// halfClosed is set once the client stopped sending, after which tool
+	// calls are denied
+	halfClosed bool
+
+	turns sync.WaitGroup
+}
+
+type sessionTurn struct {
+	seq    int32
+	cancel context.CancelFunc
+}
+
+// send sends an event of the session.
+func (c *chatSession) send(event *assistant.SessionEvent) error {
+	event.SessionId = c.id
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.stream.Send(event)
+}
+
+func (c *chatSession) sendError(seq int32, msg string) {
+	_ = c.send(&assistant.SessionEvent{
+		Seq:   seq,
+		Event: &assistant.SessionEvent_Error{Error: &assistant.SessionError{Message: msg}},
+	})
+}
+
+// startTurn runs a turn in the background, unless one is running already.
+func (c *chatSession) startTurn(ctx context.Context, turn *assistant.SessionTurn) {
+	c.mu.Lock()
+	if c.turn != nil {
+		running := c.turn.seq
+		c.mu.Unlock()
+		c.sendError(turn.Seq, fmt.Sprintf("turn %d is still running", running))
+		return
+	}
+	turnCtx, cancel := context.WithCancel(ctx)
+	c.turn = &sessionTurn{seq: turn.Seq, cancel: cancel}
+	settings := c.settings
+	c.mu.Unlock()
+
+	c.turns.Add(1)
+	go func() {
+		defer c.turns.Done()
+		defer func() {
+			c.mu.Lock()
+			c.turn = nil
+			c.mu.Unlock()
+			cancel()
+		}()
+		c.runTurn(turnCtx, turn, settings)
+	}()
+}
+
+// cancelTurn stops the running turn if it is seq; 0 matches any turn.
+func (c *chatSession) cancelTurn(seq int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.turn != nil && (seq == 0 || seq == c.turn.seq) {
+		c.turn.cancel()
+	}
+}
+
+// runTurn streams the reply to turn and ends it with a TurnEnded event.
+func (c *chatSession) runTurn(ctx context.Context, turn *assistant.SessionTurn, settings *assistant.SessionSettings) {
+	_ = c.send(&assistant.SessionEvent{Seq: turn.Seq, Event: &assistant.SessionEvent_TurnStarted{TurnStarted: &assistant.TurnStarted{}}})
+	req := &assistant.UserRequest{
+		SessionId: c.id,
+		Seq:       turn.Seq,
+		Message:   turn.Message,
+		FrontPart: turn.FrontPart,
+		BackPart:  turn.BackPart,
+		Filename:  turn.Filename,
+		Workspace: c.workspace,
+		Plugin:    settings.GetPlugin(),
+	}
+
+	var plugins []string
+	persist, err := c.s.bindSession(ctx, c.id, c.workspace)
+	scopes := requestScopes(ctx, c.id, c.workspace, persist)
+	if err == nil {
+		plugins, err = c.s.budgetedPlugins(ctx, rpcChat, req, scopes)
+	}
+
+	// Load session history and persist user message
+	var hist []shared.Message
+	if err == nil && persist {
+		hist = c.s.loadHistory(ctx, c.id, turn.Message)
+	}
+	pluginReq := shared.UserRequest{
+		SessionId: c.id,
+		Seq:       turn.Seq,
+		Message:   turn.Message,
+		FrontPart: turn.FrontPart,
+		BackPart:  turn.BackPart,
+		Filename:  turn.Filename,
+		Workspace: c.workspace,
+		History:   hist,
+	}
+	if !settings.GetAutoApproveTools() {
+		ctx = shared.WithToolApprover(ctx, &sessionApprover{c: c, seq: turn.Seq})
+	}
+
+	var reply, answered string
+	sink := func(plugin string, chunk shared.ChunkData) error {
+		answered = plugin
+		return c.sendChunk(turn.Seq, chunk)
+	}
+	acct := requestUsage{scopes: scopes}
+	defer c.s.recordRequest(pluginReq, &acct)
+	for i, name := range plugins {
+		if i > 0 {
+			log.Printf("Falling back to copilot plugin %s: %v", name, err)
+		}
+
+		var sent bool
+		answered = name
+		reply, sent, err = c.s.chatWith(ctx, name, pluginReq, sink, &acct)
+		if err == nil || sent || !retryable(ctx, err) {
+			break
+		}
+	}
+
+	ended := &assistant.TurnEnded{Reason: assistant.TurnEndReason_TURN_END_REASON_COMPLETED, Plugin: answered}
+	switch {
+	case err == nil:
+		// Persist assistant reply to session history
+		if persist {
+			c.s.saveReply(ctx, c.id, reply)
+		}
+	case ctx.Err() != nil && c.stream.Context().Err() == nil:
+		ended.Reason = assistant.TurnEndReason_TURN_END_REASON_CANCELLED
+	default:
+		ended.Reason = assistant.TurnEndReason_TURN_END_REASON_FAILED
+		ended.Error = status.Convert(err).Message()
+	}
+	_ = c.send(&assistant.SessionEvent{Seq: turn.Seq, Event: &assistant.SessionEvent_TurnEnded{TurnEnded: ended}})
+}
+
+// sendChunk sends a plugin chunk as session events, in the same order as
+// responseSink.
+func (c *chatSession) sendChunk(seq int32, chunk shared.ChunkData) error {
+	var events []*assistant.SessionEvent
+	if chunk.Reasoning != "" {
+		events = append(events, &assistant.SessionEvent{Event: &assistant.SessionEvent_Reasoning{
+			Reasoning: &assistant.ReasoningDelta{Content: chunk.Reasoning},
+		}})
+	}
+	if chunk.Content != "" {
+		events = append(events, &assistant.SessionEvent{Event: &assistant.SessionEvent_Token{
+			Token: &assistant.TokenDelta{Content: chunk.Content},
+		}})
+	}
+	if tool := chunk.Tool; tool != nil {
+		if tool.Done {
+			events = append(events, &assistant.SessionEvent{Event: &assistant.SessionEvent_ToolResult{
+				ToolResult: &assistant.ToolCallResult{Call: toolCallMessage(tool.Call), Result: tool.Result, Denied: tool.Denied},
+			}})
+		} else {
+			events = append(events, &assistant.SessionEvent{Event: &assistant.SessionEvent_ToolCall{
+				ToolCall: &assistant.ToolCallStarted{Call: toolCallMessage(tool.Call)},
+			}})
+		}
+	}
+	for _, citation := range chunk.Citations {
+		events = append(events, &assistant.SessionEvent{Event: &assistant.SessionEvent_Citation{Citation: citationMessage(citation)}})
+	}
+	if chunk.Usage != nil {
+		events = append(events, &assistant.SessionEvent{Event: &assistant.SessionEvent_Usage{Usage: usageMessage(chunk.Usage)}})
+	}
+
+	for _, event := range events {
+		event.Seq = seq
+		if err := c.send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toolCallMessage(call shared.ToolCall) *assistant.ToolCall {
+	return &assistant.ToolCall{Id: call.ID, Name: call.Name, Arguments: call.Arguments}
+}
+
+// decide delivers the client's decision on a tool call.
+func (c *chatSession) decide(d *assistant.ToolDecision) {
+	c.mu.Lock()
+	decision, ok := c.approvals[d.CallId]
+	delete(c.approvals, d.CallId)
+	c.mu.Unlock()
+	if !ok {
+		c.sendError(0, fmt.Sprintf("no tool call %q awaits a decision", d.CallId))
+		return
+	}
+	decision <- d.Approved
+}
+
+// denyApprovals denies the waiting and all further tool calls.
+func (c *chatSession) denyApprovals() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.halfClosed = true
+	for id, decision := range c.approvals {
+		decision <- false
+		delete(c.approvals, id)
+	}
+}
+
+// sessionApprover asks the client to approve the tool calls of a turn.
+type sessionApprover struct {
+	c   *chatSession
+	seq int32
+}
+
+func (a *sessionApprover) ApproveTool(ctx context.Context, call shared.ToolCall) (bool, error) {
+	c := a.c
+	c.mu.Lock()
+	if c.halfClosed {
+		c.mu.Unlock()
+		return false, nil
+	}
+	c.nextCall++
+	id := strconv.Itoa(c.nextCall)
+	decision := make(chan bool, 1)
+	c.approvals[id] = decision
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.approvals, id)
+		c.mu.Unlock()
+	}()
+
+	err := c.send(&assistant.SessionEvent{
+		Seq: a.seq,
+		Event: &assistant.SessionEvent_ToolApproval{ToolApproval: &assistant.ToolApprovalRequest{
+			CallId: id,
+			Call:   toolCallMessage(call),
+		}},
+	})
+	if err != nil {
+		return false, err
+	}
+	select {
+	case approved := <-decision:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+var _ shared.ToolApprover = (*sessionApprover)(nil)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
@@ -12,8 +13,11 @@ import (
 
 func main() {
 	ctx := context.Background()
+	// 例如 Ollama: HOMA_LLM_BASE_URL=http://localhost:11434/v1 HOMA_LLM_MODEL=qwen2.5
 	chatModel, err := llm.NewHomaChatModel(&llm.HomaChatModelConfig{
-		APIKey: "random key",
+		APIKey:  os.Getenv("HOMA_LLM_API_KEY"),
+		BaseURL: os.Getenv("HOMA_LLM_BASE_URL"),
+		Model:   os.Getenv("HOMA_LLM_MODEL"),
 	})
 	if err != nil {
 		panic(err)
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/completion"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+const (
+	defaultCompletionCacheSize = 1024
+	defaultCompletionCacheTTL  = 10 * time.Minute
+
+	// completionsPerContext bounds the completions kept for one context, one
+	// per cursor position on the line being typed
+	completionsPerContext = 8
+)
+
+// completionCache is an LRU cache of autocomplete candidates. Editors ask for
+// a completion on nearly every keystroke, so replies are grouped by their
+// context: the caller, the plugin, the workspace and file, the code after the
+// cursor and the code before the cursor line. Within a group a reply is found
+// by the text of the cursor line, or reused when the user has since typed the
+// start of it.
+type completionCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List // of *completionGroup, most recently used first
+	entries map[string]*list.Element
+}
+
+type completionGroup struct {
+	key         string
+	completions []cachedCompletion // most recent last
+}
+
+type cachedCompletion struct {
+	line       string // the cursor line up to the cursor
+	candidates []shared.Candidate
+	wanted     int    // the number of candidates asked for
+	plugin     string // the plugin that answered
+	expires    time.Time
+}
+
+// newCompletionCache creates the cache from the [autocomplete] section:
+// cache-size is the number of contexts kept (0 disables the cache) and
+// cache-ttl how long a reply may be served.
+func newCompletionCache() *completionCache {
+	conf := shared.PluginConfig(cfg.GetAppConfig().GetStringMap("autocomplete"))
+	size := conf.GetInt("cache-size", defaultCompletionCacheSize)
+	if size <= 0 {
+		return nil
+	}
+	return &completionCache{
+		size:    size,
+		ttl:     conf.GetDuration("cache-ttl", defaultCompletionCacheTTL),
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// completionKey splits a request into the hash of its context and the cursor
+// line. Line endings and trailing whitespace of the code after the cursor are
+// normalized, and only the code the plugins see is hashed. Completions are
+// shaped by the caller's session history, so they are never shared between
+// principals.
+func completionKey(principal, plugin string, req shared.UserRequest) (key, line string) {
+	in := completion.NewInput(req)
+	prefix := strings.ReplaceAll(in.Prefix, "\r\n", "\n")
+	suffix := strings.TrimRight(strings.ReplaceAll(in.Suffix, "\r\n", "\n"), " \t\n")
+
+	i := strings.LastIndexByte(prefix, '\n') + 1
+	h := sha256.New()
+	for _, part := range []string{principal, plugin, req.Workspace, req.Filename, suffix, prefix[:i]} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), prefix[i:]
+}
+
+// get returns the cached candidates for the request, or the rest of cached
+// candidates whose start the user has typed since. Replies with fewer
+// candidates than asked for now are not reused.
+func (c *completionCache) get(principal, plugin string, req shared.UserRequest, wanted int) (candidates []shared.Candidate, answeredBy string, ok bool) {
+	if c == nil {
+		return nil, "", false
+	}
+	key, line := completionKey(principal, plugin, req)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, found := c.entries[key]
+	if !found {
+		return nil, "", false
+	}
+	group := elem.Value.(*completionGroup)
+	now := time.Now()
+	for i := len(group.completions) - 1; i >= 0; i-- {
+		cached := group.completions[i]
+		if now.After(cached.expires) || cached.wanted < wanted || !strings.HasPrefix(line, cached.line) {
+			continue
+		}
+		if candidates := typedAhead(cached.candidates, line[len(cached.line):]); len(candidates) > 0 {
+			c.lru.MoveToFront(elem)
+			return candidates[:min(len(candidates), wanted)], cached.plugin, true
+		}
+	}
+	return nil, "", false
+}
+
+// typedAhead returns what is left of the candidates after the user typed
+// their first characters. Candidates replacing code before the cursor no
+// longer line up once the user typed.
+func typedAhead(candidates []shared.Candidate, typed string) []shared.Candidate {
+	if typed == "" {
+		return candidates
+	}
+	var rest []shared.Candidate
+	for _, c := range candidates {
+		if c.ReplaceBefore != 0 {
+			continue
+		}
+		if content, match := strings.CutPrefix(c.Content, typed); match && content != "" {
+			c.Content = content
+			rest = append(rest, c)
+		}
+	}
+	return rest
+}
+
+// put caches the candidates offered to principal for the request.
+func (c *completionCache) put(principal, plugin string, req shared.UserRequest, wanted int, candidates []shared.Candidate, answeredBy string) {
+	if c == nil || len(candidates) == 0 {
+		return
+	}
+	key, line := completionKey(principal, plugin, req)
+	cached := cachedCompletion{
+		line:       line,
+		candidates: candidates,
+		wanted:     wanted,
+		plugin:     answeredBy,
+		expires:    time.Now().Add(c.ttl),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, found := c.entries[key]; found {
+		group := elem.Value.(*completionGroup)
+		completions := group.completions[:0]
+		for _, old := range group.completions {
+			if old.line != line {
+				completions = append(completions, old)
+			}
+		}
+		if len(completions) >= completionsPerContext {
+			completions = completions[1:]
+		}
+		group.completions = append(completions, cached)
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&completionGroup{key: key, completions: []cachedCompletion{cached}})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*completionGroup).key)
+	}
+}
+
+// purge drops every cached reply, for when a plugin is replaced.
+func (c *completionCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	clear(c.entries)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+func TestTypedAhead(t *testing.T) {
+	candidates := []shared.Candidate{
+		{Content: "Println(x)", Score: 0.9},
+		{Content: "Printf(\"%v\", x)", Score: 0.5},
+		{Content: "Sprint(x)", Score: 0.4},
+		{Content: "fmt.Println(x)", ReplaceBefore: 4},
+	}
+
+	tests := []struct {
+		name  string
+		typed string
+		want  []string
+	}{
+		{"nothing typed", "", []string{"Println(x)", "Printf(\"%v\", x)", "Sprint(x)", "fmt.Println(x)"}},
+		{"typed a shared start", "Print", []string{"ln(x)", "f(\"%v\", x)"}},
+		{"typed one candidate's start", "Printl", []string{"n(x)"}},
+		{"typed past the whole candidate", "Println(x)", nil},
+		{"typed something else", "Fprint", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range typedAhead(candidates, tt.typed) {
+				got = append(got, c.Content)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("typedAhead(%q) = %q, want %q", tt.typed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionCacheGet(t *testing.T) {
+	c := newCompletionCache()
+	cached := shared.UserRequest{
+		FrontPart: "func main() {\n\tfmt.",
+		BackPart:  "\n}\n",
+		Filename:  "/work/app/main.go",
+		Workspace: "/work/app",
+	}
+	c.put("alice", "gemini", cached, 2, []shared.Candidate{{Content: "Println()"}, {Content: "Printf()"}}, "gemini")
+
+	with := func(edit func(*shared.UserRequest)) shared.UserRequest {
+		req := cached
+		edit(&req)
+		return req
+	}
+	tests := []struct {
+		name      string
+		principal string
+		plugin    string
+		req       shared.UserRequest
+		wanted    int
+		want      []string
+	}{
+		{"same request", "alice", "gemini", cached, 2, []string{"Println()", "Printf()"}},
+		{"fewer candidates wanted", "alice", "gemini", cached, 1, []string{"Println()"}},
+		{"more candidates wanted", "alice", "gemini", cached, 3, nil},
+		{"typed ahead", "alice", "gemini", with(func(r *shared.UserRequest) { r.FrontPart += "Printl" }), 1, []string{"n()"}},
+		{"windows line endings", "alice", "gemini", with(func(r *shared.UserRequest) { r.BackPart = "\r\n}\r\n" }), 2, []string{"Println()", "Printf()"}},
+		{"typed elsewhere", "alice", "gemini", with(func(r *shared.UserRequest) { r.FrontPart += "Sp" }), 1, nil},
+		{"other principal", "bob", "gemini", cached, 2, nil},
+		{"other plugin", "alice", "local", cached, 2, nil},
+		{"other workspace", "alice", "gemini", with(func(r *shared.UserRequest) { r.Workspace = "/work/other" }), 2, nil},
+		{"other file", "alice", "gemini", with(func(r *shared.UserRequest) { r.Filename = "/work/app/util.go" }), 2, nil},
+		{"other code before the line", "alice", "gemini", with(func(r *shared.UserRequest) { r.FrontPart = "// main\n" + r.FrontPart }), 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, answeredBy, ok := c.get(tt.principal, tt.plugin, tt.req, tt.wanted)
+			var got []string
+			for _, candidate := range candidates {
+				got = append(got, candidate.Content)
+			}
+			if !slices.Equal(got, tt.want) || ok != (tt.want != nil) {
+				t.Fatalf("get() = %q, %v; want %q", got, ok, tt.want)
+			}
+			if ok && answeredBy != "gemini" {
+				t.Errorf("answered by %q, want gemini", answeredBy)
+			}
+		})
+	}
+}
+
+func TestCompletionCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newCompletionCache()
+	c.size = 2
+	req := func(file string) shared.UserRequest {
+		return shared.UserRequest{FrontPart: "x := ", Filename: file}
+	}
+	candidates := []shared.Candidate{{Content: "1"}}
+
+	c.put("alice", "gemini", req("a.go"), 1, candidates, "gemini")
+	c.put("alice", "gemini", req("b.go"), 1, candidates, "gemini")
+	c.get("alice", "gemini", req("a.go"), 1)
+	c.put("alice", "gemini", req("c.go"), 1, candidates, "gemini")
+
+	for file, want := range map[string]bool{"a.go": true, "b.go": false, "c.go": true} {
+		if _, _, ok := c.get("alice", "gemini", req(file), 1); ok != want {
+			t.Errorf("%s cached = %v, want %v", file, ok, want)
+		}
+	}
+}
@@ -2,64 +2,118 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/qtopie/homa/gen/assistant" // Import the generated code
 	cfg "github.com/qtopie/homa/internal/app/config"
 	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/auth"
+	"github.com/qtopie/homa/internal/budget"
 	"github.com/qtopie/homa/internal/session"
+	"github.com/qtopie/homa/internal/usage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // CopilotServiceServerImpl is the implementation of the ChatService
 type CopilotServiceServerImpl struct {
 	assistant.UnimplementedCopilotServiceServer
 	pluginManager *PluginManager
-	currentPlugin CopilotPlugin
-	currentName   string
+	active        *activePlugin
 	mu            sync.Mutex
 	sessionStore  *session.EtcdStore
+	usageStore    *usage.EtcdStore
+	budgetStore   *budget.EtcdStore
+
+	// switchMu serializes plugin switches; switching is set while one runs
+	// in the background
+	switchMu  sync.Mutex
+	switching atomic.Bool
+
+	// Plugins requests are routed to besides the active one; routeMu
+	// serializes loading them
+	routeMu       sync.Mutex
+	routed        map[string]*activePlugin
+	routeFailures map[string]pluginFailure
+
+	// selected is the plugin activated through the admin service; it takes
+	// precedence over plugins.copilot until the config file changes
+	selected string
+
+	// completions caches autocomplete replies; nil when disabled
+	completions *completionCache
+	// inflight tracks each session's running autocomplete request
+	inflight *completionTracker
+	// limits queues calls to plugins with concurrency or rate limits
+	limits *pluginLimits
+
+	// Last failed plugin switch, used to avoid reloading a broken plugin on
+	// every request
+	failedName string
+	failedAt   time.Time
+	failedErr  error
 }
 
 // NewCopilotServiceServerImpl creates a new instance of CopilotServiceServerImpl
 func NewCopilotServiceServerImpl(pluginManager *PluginManager) *CopilotServiceServerImpl {
 	endpoints := cfg.GetAppConfig().GetStringSlice("etcd.endpoints")
-	if len(endpoints) == 0 {
-		endpoints = []string{"localhost:2379"}
-	}
 	store, err := session.NewEtcdStore(endpoints, 10, 0)
 	if err != nil {
 		log.Printf("failed to create etcd session store: %v", err)
 		store = nil
 	}
+	usageStore, err := usage.NewEtcdStore(endpoints)
+	if err != nil {
+		log.Printf("failed to create etcd usage store: %v", err)
+		usageStore = nil
+	}
+	budgetStore, err := budget.NewEtcdStore(endpoints)
+	if err != nil {
+		log.Printf("failed to create etcd budget store: %v", err)
+		budgetStore = nil
+	}
 	return &CopilotServiceServerImpl{
 		pluginManager: pluginManager,
 		sessionStore:  store,
+		usageStore:    usageStore,
+		budgetStore:   budgetStore,
+		routed:        make(map[string]*activePlugin),
+		routeFailures: make(map[string]pluginFailure),
+		completions:   newCompletionCache(),
+		inflight:      newCompletionTracker(),
+		limits:        newPluginLimits(),
 	}
 }
 
-// Chat implements the server streaming method for ChatService
+// Chat implements the server streaming method for ChatService. Retryable
+// plugin failures move on to the next plugin in the fallback chain, as long as
+// nothing has been streamed to the client yet.
 func (s *CopilotServiceServerImpl) Chat(req *assistant.UserRequest, stream assistant.CopilotService_ChatServer) error {
-	err := s.loadAndRefreshPlugin()
+	// The stream context is cancelled when the client goes away, which
+	// stops the plugin's upstream generation.
+	ctx := stream.Context()
+
+	persist, err := s.bindSession(ctx, req.SessionId, req.Workspace)
+	if err != nil {
+		return err
+	}
+	scopes := requestScopes(ctx, req.SessionId, req.Workspace, persist)
+	plugins, err := s.budgetedPlugins(ctx, rpcChat, req, scopes)
 	if err != nil {
-		log.Println("failed to load plugin", err)
 		return err
 	}
 
 	// Load session history and persist user message
 	var hist []shared.Message
-	if s.sessionStore != nil {
-		if h, err := s.sessionStore.GetHistory(context.Background(), req.SessionId); err == nil {
-			hist = h
-		}
-		_ = s.sessionStore.AppendHistory(context.Background(), req.SessionId, shared.Message{Role: "user", Content: req.Message, Time: time.Now().Unix()})
+	if persist {
+		hist = s.loadHistory(ctx, req.SessionId, req.Message)
 	}
-
-	// Forward the request to the plugin's Chat method
-	pluginStream, err := s.currentPlugin.Chat(shared.UserRequest{
+	pluginReq := shared.UserRequest{
 		SessionId: req.SessionId,
 		Seq:       req.Seq,
 		Message:   req.Message,
@@ -68,60 +122,150 @@ func (s *CopilotServiceServerImpl) Chat(req *assistant.UserRequest, stream assis
 		Filename:  req.Filename,
 		Workspace: req.Workspace,
 		History:   hist,
+	}
+
+	sink := &responseSink{stream: stream, req: pluginReq}
+	acct := requestUsage{scopes: scopes}
+	defer s.recordRequest(pluginReq, &acct)
+	for i, name := range plugins {
+		if i > 0 {
+			log.Printf("Falling back to copilot plugin %s: %v", name, err)
+		}
+
+		var reply string
+		var sent bool
+		reply, sent, err = s.chatWith(ctx, name, pluginReq, sink.send, &acct)
+		if err == nil {
+			// Persist assistant reply to session history
+			if persist {
+				s.saveReply(ctx, req.SessionId, reply)
+			}
+			log.Printf("Chat request completed for message: %s", req.Message)
+			return sink.finish(name)
+		}
+		if sent || !retryable(ctx, err) {
+			break
+		}
+	}
+	sink.fail(err)
+	return err
+}
+
+// chatWith streams one plugin's reply to sink. sent reports whether any
+// chunk reached the client, after which the request cannot fall back.
+func (s *CopilotServiceServerImpl) chatWith(ctx context.Context, name string, req shared.UserRequest, sink chunkSink, acct *requestUsage) (reply string, sent bool, err error) {
+	return s.streamWith(ctx, name, "Chat", sink, acct, func(ctx context.Context, p CopilotPlugin) (<-chan shared.ChunkData, error) {
+		// Forward the request to the plugin's Chat method
+		return p.Chat(ctx, req)
 	})
+}
+
+// chunkSink delivers a chunk produced by the named plugin to the client.
+type chunkSink func(plugin string, chunk shared.ChunkData) error
+
+// streamWith forwards the chunks a plugin produces to sink and returns the
+// content of the reply. method names the plugin call in logs; the call and
+// its usage are added to acct.
+func (s *CopilotServiceServerImpl) streamWith(ctx context.Context, name, method string, sink chunkSink, acct *requestUsage, call func(context.Context, CopilotPlugin) (<-chan shared.ChunkData, error)) (reply string, sent bool, err error) {
+	// Wait for the plugin's limits; the turn is held until the stream ends
+	done, err := s.limits.acquire(ctx, name, clientIdentity(ctx))
 	if err != nil {
-		log.Printf("Error calling Chat on plugin %s: %v", s.currentName, err)
-		return err
+		return "", false, err
+	}
+	defer done()
+
+	active, err := s.acquireNamed(name)
+	if err != nil {
+		log.Println("failed to load plugin", err)
+		return "", false, err
 	}
-	// Consume the plugin's stream and forward to gRPC stream
+	defer active.release()
+
+	ctx, meter := meterUsage(ctx)
+	defer func() { acct.attempt(active.name, meter.total()) }()
+
+	pluginStream, err := call(ctx, active.plugin)
+	if err != nil {
+		log.Printf("Error calling %s on plugin %s: %v", method, active.name, err)
+		return "", false, pluginStatusError(err)
+	}
+	// Consume the plugin's stream and forward to the client
 	var replyBuilder strings.Builder
 	for chunk := range pluginStream {
-		// Send each chunk to the gRPC stream
-		resp := &assistant.StreamResponse{
-			Content: chunk.Content,
+		// A failed stream must not be persisted as a complete reply
+		if chunk.Err != nil {
+			log.Printf("Plugin %s stream failed after %d bytes: %v", active.name, replyBuilder.Len(), chunk.Err)
+			return "", sent, pluginStatusError(chunk.Err)
+		}
+
+		if chunk.Usage != nil {
+			meter.ReportUsage(*chunk.Usage)
 		}
-		if err := stream.Send(resp); err != nil {
+		if err := sink(active.name, chunk); err != nil {
 			log.Printf("Error sending response to gRPC stream: %v", err)
-			return err
+			return "", true, err
 		}
+		sent = true
 		replyBuilder.WriteString(chunk.Content)
 
 		// Check if this is the last chunk
 		if chunk.IsLast {
-			log.Printf("Received end signal from plugin %s", s.currentName)
+			log.Printf("Received end signal from plugin %s", active.name)
 			break
 		}
 	}
 
-	// Persist assistant reply to session history
-	if s.sessionStore != nil {
-		reply := replyBuilder.String()
-		_ = s.sessionStore.AppendHistory(context.Background(), req.SessionId, shared.Message{Role: "assistant", Content: reply, Time: time.Now().Unix()})
+	if err := ctx.Err(); err != nil {
+		log.Printf("%s request cancelled: %v", method, err)
+		return "", sent, status.FromContextError(err).Err()
 	}
-
-	log.Printf("Chat request completed for message: %s", req.Message)
-	return nil
+	return replyBuilder.String(), sent, nil
 }
 
-// AutoComplete implements the unary method for AutoComplete
+// AutoComplete implements the unary method for AutoComplete. Retryable
+// plugin failures move on to the next plugin in the fallback chain. Cached
+// completions are answered, once the caller's access to the session is
+// checked, without calling a plugin or touching the session history. A newer
+// request of the same session cancels this one, which then
+// fails with Aborted; replies echo SessionId and Seq so clients can drop stale
+// ones.
 func (s *CopilotServiceServerImpl) AutoComplete(ctx context.Context, req *assistant.UserRequest) (*assistant.AgentResponse, error) {
-	err := s.loadAndRefreshPlugin()
+	persist, err := s.bindSession(ctx, req.SessionId, req.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	scopes := requestScopes(ctx, req.SessionId, req.Workspace, persist)
+	plugins, err := s.budgetedPlugins(ctx, rpcAutoComplete, req, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Supersede the session's previous completion
+	callCtx, done, err := s.inflight.begin(ctx, req.SessionId, req.Seq)
 	if err != nil {
-		log.Println("failed to load plugin", err)
+		return nil, err
+	}
+	defer done()
+
+	wanted := wantedCandidates(req)
+	principal := auth.Name(ctx)
+	cacheReq := shared.UserRequest{FrontPart: req.FrontPart, BackPart: req.BackPart, Filename: req.Filename, Workspace: req.Workspace}
+	if candidates, name, ok := s.completions.get(principal, plugins[0], cacheReq, wanted); ok {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(pluginHeader, name))
+		return completionResponse(req, candidates), nil
+	}
+
+	// Wait for typing to pause
+	if err := s.inflight.debounce(callCtx); err != nil {
 		return nil, err
 	}
 
 	// Load session history and persist user message
 	var hist []shared.Message
-	if s.sessionStore != nil {
-		if h, err := s.sessionStore.GetHistory(context.Background(), req.SessionId); err == nil {
-			hist = h
-		}
-		_ = s.sessionStore.AppendHistory(context.Background(), req.SessionId, shared.Message{Role: "user", Content: req.Message, Time: time.Now().Unix()})
+	if persist {
+		hist = s.loadHistory(ctx, req.SessionId, req.Message)
 	}
-
-	// Forward the request to the plugin's AutoComplete method
-	reply, err := s.currentPlugin.AutoComplete(shared.UserRequest{
+	pluginReq := shared.UserRequest{
 		SessionId: req.SessionId,
 		Seq:       req.Seq,
 		Message:   req.Message,
@@ -130,62 +274,76 @@ func (s *CopilotServiceServerImpl) AutoComplete(ctx context.Context, req *assist
 		Filename:  req.Filename,
 		Workspace: req.Workspace,
 		History:   hist,
-	})
-	if err != nil {
-		log.Printf("Error calling Chat on plugin %s: %v", s.currentName, err)
-		return nil, err
+
+		MaxCandidates: wanted,
 	}
-	log.Println("autocomplete", req.Message, "response", reply)
 
-	resp := &assistant.AgentResponse{
-		Content: reply,
+	var candidates []shared.Candidate
+	var name string
+	acct := requestUsage{scopes: scopes}
+	defer s.recordRequest(pluginReq, &acct)
+	for i, plugin := range plugins {
+		if i > 0 {
+			log.Printf("Falling back to copilot plugin %s: %v", plugin, err)
+		}
+		if candidates, name, err = s.autoCompleteWith(callCtx, plugin, pluginReq, &acct); err == nil || !retryable(callCtx, err) {
+			break
+		}
 	}
+	if err != nil {
+		if callCtx.Err() != nil && ctx.Err() == nil {
+			return nil, supersededError(callCtx)
+		}
+		return nil, err
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(pluginHeader, name))
+	candidates = rankCandidates(candidates, wanted)
+	resp := completionResponse(req, candidates)
+	log.Println("autocomplete", req.Message, "response", resp.Content)
+	s.completions.put(principal, plugins[0], cacheReq, wanted, candidates, name)
 
 	// Persist assistant reply
-	if s.sessionStore != nil {
-		_ = s.sessionStore.AppendHistory(ctx, req.SessionId, shared.Message{Role: "assistant", Content: reply, Time: time.Now().Unix()})
+	if persist {
+		s.saveReply(ctx, req.SessionId, resp.Content)
 	}
 	return resp, nil
 }
 
-func (s *CopilotServiceServerImpl) loadAndRefreshPlugin() error {
-	// Get the plugin name from the configuration
-	copilotPluginName := cfg.GetAppConfig().GetString("plugins.copilot")
-	if copilotPluginName == "" {
-		return fmt.Errorf("no copilot plugin specified in configuration")
+// autoCompleteWith asks one plugin for completions and returns the name of
+// the plugin that answered. Plugins offering several candidates are asked for
+// them when the client wants more than one. The call and its usage are added
+// to acct.
+func (s *CopilotServiceServerImpl) autoCompleteWith(ctx context.Context, name string, req shared.UserRequest, acct *requestUsage) (candidates []shared.Candidate, answered string, err error) {
+	done, err := s.limits.acquire(ctx, name, clientIdentity(ctx))
+	if err != nil {
+		return nil, "", err
 	}
+	defer done()
 
-	// Load the plugin only if it has changed
-	s.mu.Lock()
-	if s.currentName != copilotPluginName {
-		log.Printf("Loading copilot plugin: %s", copilotPluginName)
-
-		// Load the plugin dynamically
-		err := s.pluginManager.LoadPlugin("copilot", copilotPluginName)
-		if err != nil {
-			s.mu.Unlock()
-			log.Printf("Error loading copilot plugin %s: %v", copilotPluginName, err)
-			return err
-		}
+	active, err := s.acquireNamed(name)
+	if err != nil {
+		log.Println("failed to load plugin", err)
+		return nil, "", err
+	}
+	defer active.release()
 
-		// Retrieve the loaded plugin
-		plugin, exists := s.pluginManager.GetPlugin("copilot", copilotPluginName)
-		if !exists {
-			s.mu.Unlock()
-			return fmt.Errorf("copilot plugin %s not found", copilotPluginName)
-		}
+	ctx, meter := meterUsage(ctx)
+	defer func() { acct.attempt(active.name, meter.total()) }()
 
-		// Assert the plugin to the CopilotPlugin interface
-		copilotPlugin, ok := plugin.(CopilotPlugin)
-		if !ok {
-			s.mu.Unlock()
-			return fmt.Errorf("plugin %s does not implement CopilotPlugin interface", copilotPluginName)
+	// Forward the request to the plugin's AutoComplete method
+	if completer, ok := active.plugin.(shared.CandidateCompleter); ok && req.MaxCandidates > 1 {
+		candidates, err = completer.AutoCompleteCandidates(ctx, req)
+	} else {
+		var reply string
+		reply, err = active.plugin.AutoComplete(ctx, req)
+		candidates = []shared.Candidate{{Content: reply}}
+	}
+	if err != nil {
+		log.Printf("Error calling AutoComplete on plugin %s: %v", active.name, err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, "", status.FromContextError(ctxErr).Err()
 		}
-
-		// Update the current plugin and name
-		s.currentPlugin = copilotPlugin
-		s.currentName = copilotPluginName
+		return nil, "", pluginStatusError(err)
 	}
-	s.mu.Unlock()
-	return nil
+	return candidates, active.name, nil
 }
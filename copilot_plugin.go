@@ -1,9 +1,91 @@
 package main
 
-import "github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+import (
+	"context"
 
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+// CopilotPlugin is the current (v2) copilot plugin interface. The context is
+// cancelled when the client goes away or the request is superseded; plugins
+// must stop generation and close the chunk channel when that happens.
 type CopilotPlugin interface {
+	Chat(context.Context, shared.UserRequest) (<-chan shared.ChunkData, error)
+
+	AutoComplete(context.Context, shared.UserRequest) (string, error)
+}
+
+// LegacyCopilotPlugin is the v1 plugin interface without a context. Plugins
+// built against it are still accepted and wrapped by legacyCopilotPlugin.
+type LegacyCopilotPlugin interface {
 	Chat(shared.UserRequest) (<-chan shared.ChunkData, error)
 
 	AutoComplete(shared.UserRequest) (string, error)
-}
\ No newline at end of file
+}
+
+// asCopilotPlugin resolves a symbol exported by a plugin to the v2 interface,
+// adapting v1 plugins when needed.
+func asCopilotPlugin(symbol interface{}) (CopilotPlugin, bool) {
+	switch p := symbol.(type) {
+	case CopilotPlugin:
+		return p, true
+	case LegacyCopilotPlugin:
+		return legacyCopilotPlugin{p}, true
+	}
+	return nil, false
+}
+
+// legacyCopilotPlugin adapts a v1 plugin. The upstream call cannot be
+// cancelled, but the host stops forwarding chunks as soon as ctx is done.
+type legacyCopilotPlugin struct {
+	p LegacyCopilotPlugin
+}
+
+func (l legacyCopilotPlugin) Chat(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	in, err := l.p.Chat(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan shared.ChunkData)
+	go func() {
+		defer close(out)
+		defer shared.Recover(ctx, out)
+		// Drain the plugin channel so its goroutine can finish after a cancel
+		defer func() {
+			for range in {
+			}
+		}()
+
+		for chunk := range in {
+			if !shared.Send(ctx, out, chunk) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (l legacyCopilotPlugin) AutoComplete(ctx context.Context, req shared.UserRequest) (string, error) {
+	type result struct {
+		reply string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: shared.Errorf(shared.ErrInternal, "plugin panic: %v", r)}
+			}
+		}()
+		reply, err := l.p.AutoComplete(req)
+		done <- result{reply, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-done:
+		return r.reply, r.err
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pluginStatusError converts an error reported by a plugin into a gRPC status
+// error with a code matching the failure kind.
+func pluginStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	pe := shared.AsPluginError(err)
+	return status.Error(statusCode(pe.Kind), pe.Error())
+}
+
+func statusCode(kind shared.ErrorKind) codes.Code {
+	switch kind {
+	case shared.ErrRateLimited:
+		return codes.ResourceExhausted
+	case shared.ErrAuth:
+		// The server's own upstream credentials were rejected; that is not
+		// the client's permission to report.
+		return codes.Internal
+	case shared.ErrUnavailable:
+		return codes.Unavailable
+	case shared.ErrTimeout:
+		return codes.DeadlineExceeded
+	case shared.ErrContentBlocked:
+		return codes.FailedPrecondition
+	case shared.ErrInvalidRequest:
+		return codes.InvalidArgument
+	case shared.ErrCanceled:
+		return codes.Canceled
+	default:
+		return codes.Internal
+	}
+}
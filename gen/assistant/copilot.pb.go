@@ -21,6 +21,159 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type CompletionKind int32
+
+const (
+	CompletionKind_COMPLETION_KIND_UNSPECIFIED CompletionKind = 0
+	CompletionKind_COMPLETION_KIND_SINGLE_LINE CompletionKind = 1
+	CompletionKind_COMPLETION_KIND_MULTI_LINE  CompletionKind = 2
+)
+
+// Enum value maps for CompletionKind.
+var (
+	CompletionKind_name = map[int32]string{
+		0: "COMPLETION_KIND_UNSPECIFIED",
+		1: "COMPLETION_KIND_SINGLE_LINE",
+		2: "COMPLETION_KIND_MULTI_LINE",
+	}
+	CompletionKind_value = map[string]int32{
+		"COMPLETION_KIND_UNSPECIFIED": 0,
+		"COMPLETION_KIND_SINGLE_LINE": 1,
+		"COMPLETION_KIND_MULTI_LINE":  2,
+	}
+)
+
+func (x CompletionKind) Enum() *CompletionKind {
+	p := new(CompletionKind)
+	*p = x
+	return p
+}
+
+func (x CompletionKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CompletionKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_assistant_copilot_proto_enumTypes[0].Descriptor()
+}
+
+func (CompletionKind) Type() protoreflect.EnumType {
+	return &file_assistant_copilot_proto_enumTypes[0]
+}
+
+func (x CompletionKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CompletionKind.Descriptor instead.
+func (CompletionKind) EnumDescriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{0}
+}
+
+type FinishReason int32
+
+const (
+	FinishReason_FINISH_REASON_UNSPECIFIED    FinishReason = 0
+	FinishReason_FINISH_REASON_STOP           FinishReason = 1
+	FinishReason_FINISH_REASON_LENGTH         FinishReason = 2
+	FinishReason_FINISH_REASON_CONTENT_FILTER FinishReason = 3
+)
+
+// Enum value maps for FinishReason.
+var (
+	FinishReason_name = map[int32]string{
+		0: "FINISH_REASON_UNSPECIFIED",
+		1: "FINISH_REASON_STOP",
+		2: "FINISH_REASON_LENGTH",
+		3: "FINISH_REASON_CONTENT_FILTER",
+	}
+	FinishReason_value = map[string]int32{
+		"FINISH_REASON_UNSPECIFIED":    0,
+		"FINISH_REASON_STOP":           1,
+		"FINISH_REASON_LENGTH":         2,
+		"FINISH_REASON_CONTENT_FILTER": 3,
+	}
+)
+
+func (x FinishReason) Enum() *FinishReason {
+	p := new(FinishReason)
+	*p = x
+	return p
+}
+
+func (x FinishReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FinishReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_assistant_copilot_proto_enumTypes[1].Descriptor()
+}
+
+func (FinishReason) Type() protoreflect.EnumType {
+	return &file_assistant_copilot_proto_enumTypes[1]
+}
+
+func (x FinishReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FinishReason.Descriptor instead.
+func (FinishReason) EnumDescriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{1}
+}
+
+type TurnEndReason int32
+
+const (
+	TurnEndReason_TURN_END_REASON_UNSPECIFIED TurnEndReason = 0
+	TurnEndReason_TURN_END_REASON_COMPLETED   TurnEndReason = 1
+	TurnEndReason_TURN_END_REASON_CANCELLED   TurnEndReason = 2
+	TurnEndReason_TURN_END_REASON_FAILED      TurnEndReason = 3
+)
+
+// Enum value maps for TurnEndReason.
+var (
+	TurnEndReason_name = map[int32]string{
+		0: "TURN_END_REASON_UNSPECIFIED",
+		1: "TURN_END_REASON_COMPLETED",
+		2: "TURN_END_REASON_CANCELLED",
+		3: "TURN_END_REASON_FAILED",
+	}
+	TurnEndReason_value = map[string]int32{
+		"TURN_END_REASON_UNSPECIFIED": 0,
+		"TURN_END_REASON_COMPLETED":   1,
+		"TURN_END_REASON_CANCELLED":   2,
+		"TURN_END_REASON_FAILED":      3,
+	}
+)
+
+func (x TurnEndReason) Enum() *TurnEndReason {
+	p := new(TurnEndReason)
+	*p = x
+	return p
+}
+
+func (x TurnEndReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TurnEndReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_assistant_copilot_proto_enumTypes[2].Descriptor()
+}
+
+func (TurnEndReason) Type() protoreflect.EnumType {
+	return &file_assistant_copilot_proto_enumTypes[2]
+}
+
+func (x TurnEndReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TurnEndReason.Descriptor instead.
+func (TurnEndReason) EnumDescriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{2}
+}
+
 type UserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
@@ -30,6 +183,8 @@ type UserRequest struct {
 	BackPart      string                 `protobuf:"bytes,5,opt,name=backPart,proto3" json:"backPart,omitempty"`
 	Filename      string                 `protobuf:"bytes,6,opt,name=filename,proto3" json:"filename,omitempty"`
 	Workspace     string                 `protobuf:"bytes,7,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	Plugin        string                 `protobuf:"bytes,8,opt,name=plugin,proto3" json:"plugin,omitempty"`                // preferred copilot plugin, subject to routing rules
+	MaxCandidates int32                  `protobuf:"varint,9,opt,name=maxCandidates,proto3" json:"maxCandidates,omitempty"` // completion candidates wanted, 0 means 1
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -113,11 +268,26 @@ func (x *UserRequest) GetWorkspace() string {
 	return ""
 }
 
+func (x *UserRequest) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *UserRequest) GetMaxCandidates() int32 {
+	if x != nil {
+		return x.MaxCandidates
+	}
+	return 0
+}
+
 type AgentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
 	Seq           int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
-	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`       // the best candidate's content
+	Candidates    []*CompletionCandidate `protobuf:"bytes,4,rep,name=candidates,proto3" json:"candidates,omitempty"` // best first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -173,18 +343,163 @@ func (x *AgentResponse) GetContent() string {
 	return ""
 }
 
-type StreamResponse struct {
+func (x *AgentResponse) GetCandidates() []*CompletionCandidate {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+// Text to replace, in characters relative to the cursor: start <= 0 reaches
+// back into the code before the cursor, end >= 0 into the code after it.
+// An empty range inserts at the cursor.
+type Range struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
-	Seq           int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
-	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Range) Reset() {
+	*x = Range{}
+	mi := &file_assistant_copilot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Range) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Range) ProtoMessage() {}
+
+func (x *Range) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Range.ProtoReflect.Descriptor instead.
+func (*Range) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Range) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Range) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type CompletionCandidate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Range         *Range                 `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	Score         float32                `protobuf:"fixed32,3,opt,name=score,proto3" json:"score,omitempty"` // confidence between 0 and 1
+	Kind          CompletionKind         `protobuf:"varint,4,opt,name=kind,proto3,enum=assistant.CompletionKind" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompletionCandidate) Reset() {
+	*x = CompletionCandidate{}
+	mi := &file_assistant_copilot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompletionCandidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompletionCandidate) ProtoMessage() {}
+
+func (x *CompletionCandidate) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompletionCandidate.ProtoReflect.Descriptor instead.
+func (*CompletionCandidate) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CompletionCandidate) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CompletionCandidate) GetRange() *Range {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *CompletionCandidate) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *CompletionCandidate) GetKind() CompletionKind {
+	if x != nil {
+		return x.Kind
+	}
+	return CompletionKind_COMPLETION_KIND_UNSPECIFIED
+}
+
+// StreamResponse is one event of a streamed reply. Text deltas are also
+// carried in content, for clients that only read that; it is empty for the
+// other events.
+type StreamResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
+	Seq       int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Content   string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Id        string                 `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"` // the plugin's chunk id
+	// Types that are valid to be assigned to Event:
+	//
+	//	*StreamResponse_Text
+	//	*StreamResponse_Reasoning
+	//	*StreamResponse_ToolCall
+	//	*StreamResponse_ToolResult
+	//	*StreamResponse_Citation
+	//	*StreamResponse_Usage
+	//	*StreamResponse_Final
+	//	*StreamResponse_Error
+	Event         isStreamResponse_Event `protobuf_oneof:"event"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StreamResponse) Reset() {
 	*x = StreamResponse{}
-	mi := &file_assistant_copilot_proto_msgTypes[2]
+	mi := &file_assistant_copilot_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -196,7 +511,7 @@ func (x *StreamResponse) String() string {
 func (*StreamResponse) ProtoMessage() {}
 
 func (x *StreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_assistant_copilot_proto_msgTypes[2]
+	mi := &file_assistant_copilot_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -209,7 +524,7 @@ func (x *StreamResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamResponse.ProtoReflect.Descriptor instead.
 func (*StreamResponse) Descriptor() ([]byte, []int) {
-	return file_assistant_copilot_proto_rawDescGZIP(), []int{2}
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *StreamResponse) GetSessionId() string {
@@ -233,78 +548,1765 @@ func (x *StreamResponse) GetContent() string {
 	return ""
 }
 
-var File_assistant_copilot_proto protoreflect.FileDescriptor
+func (x *StreamResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
 
-const file_assistant_copilot_proto_rawDesc = "" +
-	"\n" +
-	"\x17assistant/copilot.proto\x12\tassistant\"\xcb\x01\n" +
-	"\vUserRequest\x12\x1c\n" +
-	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
-	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
-	"\tfrontPart\x18\x04 \x01(\tR\tfrontPart\x12\x1a\n" +
-	"\bbackPart\x18\x05 \x01(\tR\bbackPart\x12\x1a\n" +
-	"\bfilename\x18\x06 \x01(\tR\bfilename\x12\x1c\n" +
-	"\tworkspace\x18\a \x01(\tR\tworkspace\"Y\n" +
-	"\rAgentResponse\x12\x1c\n" +
-	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
-	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x18\n" +
-	"\acontent\x18\x03 \x01(\tR\acontent\"Z\n" +
-	"\x0eStreamResponse\x12\x1c\n" +
-	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
-	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x18\n" +
-	"\acontent\x18\x03 \x01(\tR\acontent2\x8f\x01\n" +
-	"\x0eCopilotService\x12;\n" +
-	"\x04Chat\x12\x16.assistant.UserRequest\x1a\x19.assistant.StreamResponse0\x01\x12@\n" +
-	"\fAutoComplete\x12\x16.assistant.UserRequest\x1a\x18.assistant.AgentResponseB&Z$github.com/qtopie/homa/gen/assistantb\x06proto3"
+func (x *StreamResponse) GetEvent() isStreamResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
 
-var (
-	file_assistant_copilot_proto_rawDescOnce sync.Once
-	file_assistant_copilot_proto_rawDescData []byte
-)
+func (x *StreamResponse) GetText() *TokenDelta {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Text); ok {
+			return x.Text
+		}
+	}
+	return nil
+}
 
-func file_assistant_copilot_proto_rawDescGZIP() []byte {
-	file_assistant_copilot_proto_rawDescOnce.Do(func() {
-		file_assistant_copilot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_assistant_copilot_proto_rawDesc), len(file_assistant_copilot_proto_rawDesc)))
-	})
-	return file_assistant_copilot_proto_rawDescData
+func (x *StreamResponse) GetReasoning() *ReasoningDelta {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Reasoning); ok {
+			return x.Reasoning
+		}
+	}
+	return nil
 }
 
-var file_assistant_copilot_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
-var file_assistant_copilot_proto_goTypes = []any{
-	(*UserRequest)(nil),    // 0: assistant.UserRequest
-	(*AgentResponse)(nil),  // 1: assistant.AgentResponse
-	(*StreamResponse)(nil), // 2: assistant.StreamResponse
+func (x *StreamResponse) GetToolCall() *ToolCallStarted {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_ToolCall); ok {
+			return x.ToolCall
+		}
+	}
+	return nil
 }
-var file_assistant_copilot_proto_depIdxs = []int32{
-	0, // 0: assistant.CopilotService.Chat:input_type -> assistant.UserRequest
-	0, // 1: assistant.CopilotService.AutoComplete:input_type -> assistant.UserRequest
-	2, // 2: assistant.CopilotService.Chat:output_type -> assistant.StreamResponse
-	1, // 3: assistant.CopilotService.AutoComplete:output_type -> assistant.AgentResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+
+func (x *StreamResponse) GetToolResult() *ToolCallResult {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_ToolResult); ok {
+			return x.ToolResult
+		}
+	}
+	return nil
 }
 
-func init() { file_assistant_copilot_proto_init() }
-func file_assistant_copilot_proto_init() {
-	if File_assistant_copilot_proto != nil {
-		return
+func (x *StreamResponse) GetCitation() *Citation {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Citation); ok {
+			return x.Citation
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetUsage() *Usage {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Usage); ok {
+			return x.Usage
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetFinal() *StreamFinal {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Final); ok {
+			return x.Final
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetError() *StreamError {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isStreamResponse_Event interface {
+	isStreamResponse_Event()
+}
+
+type StreamResponse_Text struct {
+	Text *TokenDelta `protobuf:"bytes,5,opt,name=text,proto3,oneof"`
+}
+
+type StreamResponse_Reasoning struct {
+	Reasoning *ReasoningDelta `protobuf:"bytes,6,opt,name=reasoning,proto3,oneof"`
+}
+
+type StreamResponse_ToolCall struct {
+	ToolCall *ToolCallStarted `protobuf:"bytes,7,opt,name=toolCall,proto3,oneof"`
+}
+
+type StreamResponse_ToolResult struct {
+	ToolResult *ToolCallResult `protobuf:"bytes,8,opt,name=toolResult,proto3,oneof"`
+}
+
+type StreamResponse_Citation struct {
+	Citation *Citation `protobuf:"bytes,9,opt,name=citation,proto3,oneof"`
+}
+
+type StreamResponse_Usage struct {
+	Usage *Usage `protobuf:"bytes,10,opt,name=usage,proto3,oneof"`
+}
+
+type StreamResponse_Final struct {
+	Final *StreamFinal `protobuf:"bytes,11,opt,name=final,proto3,oneof"` // the last event of a reply that completed
+}
+
+type StreamResponse_Error struct {
+	Error *StreamError `protobuf:"bytes,12,opt,name=error,proto3,oneof"` // the last event of a reply that failed
+}
+
+func (*StreamResponse_Text) isStreamResponse_Event() {}
+
+func (*StreamResponse_Reasoning) isStreamResponse_Event() {}
+
+func (*StreamResponse_ToolCall) isStreamResponse_Event() {}
+
+func (*StreamResponse_ToolResult) isStreamResponse_Event() {}
+
+func (*StreamResponse_Citation) isStreamResponse_Event() {}
+
+func (*StreamResponse_Usage) isStreamResponse_Event() {}
+
+func (*StreamResponse_Final) isStreamResponse_Event() {}
+
+func (*StreamResponse_Error) isStreamResponse_Event() {}
+
+// ReasoningDelta is part of the model's thinking, not of the reply.
+type ReasoningDelta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReasoningDelta) Reset() {
+	*x = ReasoningDelta{}
+	mi := &file_assistant_copilot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReasoningDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReasoningDelta) ProtoMessage() {}
+
+func (x *ReasoningDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReasoningDelta.ProtoReflect.Descriptor instead.
+func (*ReasoningDelta) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReasoningDelta) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// Citation is a source backing the reply; the indexes locate the cited text
+// in bytes of the reply when the model reports them.
+type Citation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uri           string                 `protobuf:"bytes,1,opt,name=uri,proto3" json:"uri,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	StartIndex    int32                  `protobuf:"varint,3,opt,name=startIndex,proto3" json:"startIndex,omitempty"`
+	EndIndex      int32                  `protobuf:"varint,4,opt,name=endIndex,proto3" json:"endIndex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Citation) Reset() {
+	*x = Citation{}
+	mi := &file_assistant_copilot_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Citation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Citation) ProtoMessage() {}
+
+func (x *Citation) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Citation.ProtoReflect.Descriptor instead.
+func (*Citation) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Citation) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+func (x *Citation) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Citation) GetStartIndex() int32 {
+	if x != nil {
+		return x.StartIndex
+	}
+	return 0
+}
+
+func (x *Citation) GetEndIndex() int32 {
+	if x != nil {
+		return x.EndIndex
+	}
+	return 0
+}
+
+type Usage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PromptTokens     int32                  `protobuf:"varint,1,opt,name=promptTokens,proto3" json:"promptTokens,omitempty"`
+	CompletionTokens int32                  `protobuf:"varint,2,opt,name=completionTokens,proto3" json:"completionTokens,omitempty"`
+	TotalTokens      int32                  `protobuf:"varint,3,opt,name=totalTokens,proto3" json:"totalTokens,omitempty"`
+	CachedTokens     int32                  `protobuf:"varint,4,opt,name=cachedTokens,proto3" json:"cachedTokens,omitempty"` // part of promptTokens served from a context cache
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_assistant_copilot_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Usage) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCachedTokens() int32 {
+	if x != nil {
+		return x.CachedTokens
+	}
+	return 0
+}
+
+type StreamFinal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FinishReason  FinishReason           `protobuf:"varint,1,opt,name=finishReason,proto3,enum=assistant.FinishReason" json:"finishReason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamFinal) Reset() {
+	*x = StreamFinal{}
+	mi := &file_assistant_copilot_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamFinal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamFinal) ProtoMessage() {}
+
+func (x *StreamFinal) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamFinal.ProtoReflect.Descriptor instead.
+func (*StreamFinal) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamFinal) GetFinishReason() FinishReason {
+	if x != nil {
+		return x.FinishReason
+	}
+	return FinishReason_FINISH_REASON_UNSPECIFIED
+}
+
+// StreamError precedes the status the stream fails with.
+type StreamError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"` // gRPC status code name
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamError) Reset() {
+	*x = StreamError{}
+	mi := &file_assistant_copilot_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamError) ProtoMessage() {}
+
+func (x *StreamError) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamError.ProtoReflect.Descriptor instead.
+func (*StreamError) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *StreamError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SessionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*SessionRequest_Start
+	//	*SessionRequest_Turn
+	//	*SessionRequest_Cancel
+	//	*SessionRequest_ToolDecision
+	//	*SessionRequest_Settings
+	Request       isSessionRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionRequest) Reset() {
+	*x = SessionRequest{}
+	mi := &file_assistant_copilot_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionRequest) ProtoMessage() {}
+
+func (x *SessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionRequest.ProtoReflect.Descriptor instead.
+func (*SessionRequest) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SessionRequest) GetRequest() isSessionRequest_Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *SessionRequest) GetStart() *SessionStart {
+	if x != nil {
+		if x, ok := x.Request.(*SessionRequest_Start); ok {
+			return x.Start
+		}
+	}
+	return nil
+}
+
+func (x *SessionRequest) GetTurn() *SessionTurn {
+	if x != nil {
+		if x, ok := x.Request.(*SessionRequest_Turn); ok {
+			return x.Turn
+		}
+	}
+	return nil
+}
+
+func (x *SessionRequest) GetCancel() *CancelTurn {
+	if x != nil {
+		if x, ok := x.Request.(*SessionRequest_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+func (x *SessionRequest) GetToolDecision() *ToolDecision {
+	if x != nil {
+		if x, ok := x.Request.(*SessionRequest_ToolDecision); ok {
+			return x.ToolDecision
+		}
+	}
+	return nil
+}
+
+func (x *SessionRequest) GetSettings() *SessionSettings {
+	if x != nil {
+		if x, ok := x.Request.(*SessionRequest_Settings); ok {
+			return x.Settings
+		}
+	}
+	return nil
+}
+
+type isSessionRequest_Request interface {
+	isSessionRequest_Request()
+}
+
+type SessionRequest_Start struct {
+	Start *SessionStart `protobuf:"bytes,1,opt,name=start,proto3,oneof"`
+}
+
+type SessionRequest_Turn struct {
+	Turn *SessionTurn `protobuf:"bytes,2,opt,name=turn,proto3,oneof"`
+}
+
+type SessionRequest_Cancel struct {
+	Cancel *CancelTurn `protobuf:"bytes,3,opt,name=cancel,proto3,oneof"`
+}
+
+type SessionRequest_ToolDecision struct {
+	ToolDecision *ToolDecision `protobuf:"bytes,4,opt,name=toolDecision,proto3,oneof"`
+}
+
+type SessionRequest_Settings struct {
+	Settings *SessionSettings `protobuf:"bytes,5,opt,name=settings,proto3,oneof"`
+}
+
+func (*SessionRequest_Start) isSessionRequest_Request() {}
+
+func (*SessionRequest_Turn) isSessionRequest_Request() {}
+
+func (*SessionRequest_Cancel) isSessionRequest_Request() {}
+
+func (*SessionRequest_ToolDecision) isSessionRequest_Request() {}
+
+func (*SessionRequest_Settings) isSessionRequest_Request() {}
+
+// SessionStart must be the first request of a session stream.
+type SessionStart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
+	Workspace     string                 `protobuf:"bytes,2,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	Settings      *SessionSettings       `protobuf:"bytes,3,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionStart) Reset() {
+	*x = SessionStart{}
+	mi := &file_assistant_copilot_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionStart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionStart) ProtoMessage() {}
+
+func (x *SessionStart) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionStart.ProtoReflect.Descriptor instead.
+func (*SessionStart) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SessionStart) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionStart) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+func (x *SessionStart) GetSettings() *SessionSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+// SessionSettings replace the session's settings for the turns started
+// after them.
+type SessionSettings struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Plugin           string                 `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`                      // preferred copilot plugin, subject to routing rules
+	AutoApproveTools bool                   `protobuf:"varint,2,opt,name=autoApproveTools,proto3" json:"autoApproveTools,omitempty"` // run tool calls without asking the client
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SessionSettings) Reset() {
+	*x = SessionSettings{}
+	mi := &file_assistant_copilot_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionSettings) ProtoMessage() {}
+
+func (x *SessionSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionSettings.ProtoReflect.Descriptor instead.
+func (*SessionSettings) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SessionSettings) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *SessionSettings) GetAutoApproveTools() bool {
+	if x != nil {
+		return x.AutoApproveTools
+	}
+	return false
+}
+
+// SessionTurn is a user message. Only one turn runs at a time; cancel the
+// running one to start another.
+type SessionTurn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int32                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	FrontPart     string                 `protobuf:"bytes,3,opt,name=frontPart,proto3" json:"frontPart,omitempty"`
+	BackPart      string                 `protobuf:"bytes,4,opt,name=backPart,proto3" json:"backPart,omitempty"`
+	Filename      string                 `protobuf:"bytes,5,opt,name=filename,proto3" json:"filename,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionTurn) Reset() {
+	*x = SessionTurn{}
+	mi := &file_assistant_copilot_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionTurn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionTurn) ProtoMessage() {}
+
+func (x *SessionTurn) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionTurn.ProtoReflect.Descriptor instead.
+func (*SessionTurn) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SessionTurn) GetSeq() int32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SessionTurn) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SessionTurn) GetFrontPart() string {
+	if x != nil {
+		return x.FrontPart
+	}
+	return ""
+}
+
+func (x *SessionTurn) GetBackPart() string {
+	if x != nil {
+		return x.BackPart
+	}
+	return ""
+}
+
+func (x *SessionTurn) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+// CancelTurn stops the running turn; seq 0 matches any turn.
+type CancelTurn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int32                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTurn) Reset() {
+	*x = CancelTurn{}
+	mi := &file_assistant_copilot_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTurn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTurn) ProtoMessage() {}
+
+func (x *CancelTurn) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTurn.ProtoReflect.Descriptor instead.
+func (*CancelTurn) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CancelTurn) GetSeq() int32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// ToolDecision answers a ToolApprovalRequest.
+type ToolDecision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CallId        string                 `protobuf:"bytes,1,opt,name=callId,proto3" json:"callId,omitempty"`
+	Approved      bool                   `protobuf:"varint,2,opt,name=approved,proto3" json:"approved,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolDecision) Reset() {
+	*x = ToolDecision{}
+	mi := &file_assistant_copilot_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolDecision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolDecision) ProtoMessage() {}
+
+func (x *ToolDecision) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolDecision.ProtoReflect.Descriptor instead.
+func (*ToolDecision) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ToolDecision) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *ToolDecision) GetApproved() bool {
+	if x != nil {
+		return x.Approved
+	}
+	return false
+}
+
+type SessionEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=sessionId,proto3" json:"sessionId,omitempty"`
+	Seq       int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"` // the turn the event belongs to
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SessionEvent_TurnStarted
+	//	*SessionEvent_Token
+	//	*SessionEvent_ToolApproval
+	//	*SessionEvent_ToolCall
+	//	*SessionEvent_ToolResult
+	//	*SessionEvent_TurnEnded
+	//	*SessionEvent_Error
+	//	*SessionEvent_Reasoning
+	//	*SessionEvent_Citation
+	//	*SessionEvent_Usage
+	Event         isSessionEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionEvent) Reset() {
+	*x = SessionEvent{}
+	mi := &file_assistant_copilot_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEvent) ProtoMessage() {}
+
+func (x *SessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SessionEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetSeq() int32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SessionEvent) GetEvent() isSessionEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetTurnStarted() *TurnStarted {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_TurnStarted); ok {
+			return x.TurnStarted
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetToken() *TokenDelta {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Token); ok {
+			return x.Token
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetToolApproval() *ToolApprovalRequest {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_ToolApproval); ok {
+			return x.ToolApproval
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetToolCall() *ToolCallStarted {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_ToolCall); ok {
+			return x.ToolCall
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetToolResult() *ToolCallResult {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_ToolResult); ok {
+			return x.ToolResult
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetTurnEnded() *TurnEnded {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_TurnEnded); ok {
+			return x.TurnEnded
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetError() *SessionError {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetReasoning() *ReasoningDelta {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Reasoning); ok {
+			return x.Reasoning
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetCitation() *Citation {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Citation); ok {
+			return x.Citation
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetUsage() *Usage {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Usage); ok {
+			return x.Usage
+		}
+	}
+	return nil
+}
+
+type isSessionEvent_Event interface {
+	isSessionEvent_Event()
+}
+
+type SessionEvent_TurnStarted struct {
+	TurnStarted *TurnStarted `protobuf:"bytes,3,opt,name=turnStarted,proto3,oneof"`
+}
+
+type SessionEvent_Token struct {
+	Token *TokenDelta `protobuf:"bytes,4,opt,name=token,proto3,oneof"`
+}
+
+type SessionEvent_ToolApproval struct {
+	ToolApproval *ToolApprovalRequest `protobuf:"bytes,5,opt,name=toolApproval,proto3,oneof"`
+}
+
+type SessionEvent_ToolCall struct {
+	ToolCall *ToolCallStarted `protobuf:"bytes,6,opt,name=toolCall,proto3,oneof"`
+}
+
+type SessionEvent_ToolResult struct {
+	ToolResult *ToolCallResult `protobuf:"bytes,7,opt,name=toolResult,proto3,oneof"`
+}
+
+type SessionEvent_TurnEnded struct {
+	TurnEnded *TurnEnded `protobuf:"bytes,8,opt,name=turnEnded,proto3,oneof"`
+}
+
+type SessionEvent_Error struct {
+	Error *SessionError `protobuf:"bytes,9,opt,name=error,proto3,oneof"`
+}
+
+type SessionEvent_Reasoning struct {
+	Reasoning *ReasoningDelta `protobuf:"bytes,10,opt,name=reasoning,proto3,oneof"`
+}
+
+type SessionEvent_Citation struct {
+	Citation *Citation `protobuf:"bytes,11,opt,name=citation,proto3,oneof"`
+}
+
+type SessionEvent_Usage struct {
+	Usage *Usage `protobuf:"bytes,12,opt,name=usage,proto3,oneof"`
+}
+
+func (*SessionEvent_TurnStarted) isSessionEvent_Event() {}
+
+func (*SessionEvent_Token) isSessionEvent_Event() {}
+
+func (*SessionEvent_ToolApproval) isSessionEvent_Event() {}
+
+func (*SessionEvent_ToolCall) isSessionEvent_Event() {}
+
+func (*SessionEvent_ToolResult) isSessionEvent_Event() {}
+
+func (*SessionEvent_TurnEnded) isSessionEvent_Event() {}
+
+func (*SessionEvent_Error) isSessionEvent_Event() {}
+
+func (*SessionEvent_Reasoning) isSessionEvent_Event() {}
+
+func (*SessionEvent_Citation) isSessionEvent_Event() {}
+
+func (*SessionEvent_Usage) isSessionEvent_Event() {}
+
+type TurnStarted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnStarted) Reset() {
+	*x = TurnStarted{}
+	mi := &file_assistant_copilot_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnStarted) ProtoMessage() {}
+
+func (x *TurnStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnStarted.ProtoReflect.Descriptor instead.
+func (*TurnStarted) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{17}
+}
+
+type TokenDelta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenDelta) Reset() {
+	*x = TokenDelta{}
+	mi := &file_assistant_copilot_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenDelta) ProtoMessage() {}
+
+func (x *TokenDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenDelta.ProtoReflect.Descriptor instead.
+func (*TokenDelta) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TokenDelta) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ToolCall struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Arguments     string                 `protobuf:"bytes,3,opt,name=arguments,proto3" json:"arguments,omitempty"` // JSON
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCall) Reset() {
+	*x = ToolCall{}
+	mi := &file_assistant_copilot_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCall) ProtoMessage() {}
+
+func (x *ToolCall) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
+func (*ToolCall) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ToolCall) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ToolCall) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolCall) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+// The turn waits until the client answers with a ToolDecision for callId.
+type ToolApprovalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CallId        string                 `protobuf:"bytes,1,opt,name=callId,proto3" json:"callId,omitempty"`
+	Call          *ToolCall              `protobuf:"bytes,2,opt,name=call,proto3" json:"call,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolApprovalRequest) Reset() {
+	*x = ToolApprovalRequest{}
+	mi := &file_assistant_copilot_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolApprovalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolApprovalRequest) ProtoMessage() {}
+
+func (x *ToolApprovalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolApprovalRequest.ProtoReflect.Descriptor instead.
+func (*ToolApprovalRequest) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ToolApprovalRequest) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *ToolApprovalRequest) GetCall() *ToolCall {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+type ToolCallStarted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Call          *ToolCall              `protobuf:"bytes,1,opt,name=call,proto3" json:"call,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallStarted) Reset() {
+	*x = ToolCallStarted{}
+	mi := &file_assistant_copilot_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallStarted) ProtoMessage() {}
+
+func (x *ToolCallStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallStarted.ProtoReflect.Descriptor instead.
+func (*ToolCallStarted) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ToolCallStarted) GetCall() *ToolCall {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+type ToolCallResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Call          *ToolCall              `protobuf:"bytes,1,opt,name=call,proto3" json:"call,omitempty"`
+	Result        string                 `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	Denied        bool                   `protobuf:"varint,3,opt,name=denied,proto3" json:"denied,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallResult) Reset() {
+	*x = ToolCallResult{}
+	mi := &file_assistant_copilot_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallResult) ProtoMessage() {}
+
+func (x *ToolCallResult) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallResult.ProtoReflect.Descriptor instead.
+func (*ToolCallResult) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ToolCallResult) GetCall() *ToolCall {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+func (x *ToolCallResult) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *ToolCallResult) GetDenied() bool {
+	if x != nil {
+		return x.Denied
+	}
+	return false
+}
+
+type TurnEnded struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        TurnEndReason          `protobuf:"varint,1,opt,name=reason,proto3,enum=assistant.TurnEndReason" json:"reason,omitempty"`
+	Plugin        string                 `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"` // the plugin that answered
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnEnded) Reset() {
+	*x = TurnEnded{}
+	mi := &file_assistant_copilot_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnEnded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnEnded) ProtoMessage() {}
+
+func (x *TurnEnded) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnEnded.ProtoReflect.Descriptor instead.
+func (*TurnEnded) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TurnEnded) GetReason() TurnEndReason {
+	if x != nil {
+		return x.Reason
+	}
+	return TurnEndReason_TURN_END_REASON_UNSPECIFIED
+}
+
+func (x *TurnEnded) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *TurnEnded) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// SessionError reports a request the server could not act on; the session
+// stays open.
+type SessionError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionError) Reset() {
+	*x = SessionError{}
+	mi := &file_assistant_copilot_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionError) ProtoMessage() {}
+
+func (x *SessionError) ProtoReflect() protoreflect.Message {
+	mi := &file_assistant_copilot_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionError.ProtoReflect.Descriptor instead.
+func (*SessionError) Descriptor() ([]byte, []int) {
+	return file_assistant_copilot_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SessionError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_assistant_copilot_proto protoreflect.FileDescriptor
+
+const file_assistant_copilot_proto_rawDesc = "" +
+	"\n" +
+	"\x17assistant/copilot.proto\x12\tassistant\"\x89\x02\n" +
+	"\vUserRequest\x12\x1c\n" +
+	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
+	"\tfrontPart\x18\x04 \x01(\tR\tfrontPart\x12\x1a\n" +
+	"\bbackPart\x18\x05 \x01(\tR\bbackPart\x12\x1a\n" +
+	"\bfilename\x18\x06 \x01(\tR\bfilename\x12\x1c\n" +
+	"\tworkspace\x18\a \x01(\tR\tworkspace\x12\x16\n" +
+	"\x06plugin\x18\b \x01(\tR\x06plugin\x12$\n" +
+	"\rmaxCandidates\x18\t \x01(\x05R\rmaxCandidates\"\x99\x01\n" +
+	"\rAgentResponse\x12\x1c\n" +
+	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12>\n" +
+	"\n" +
+	"candidates\x18\x04 \x03(\v2\x1e.assistant.CompletionCandidateR\n" +
+	"candidates\"/\n" +
+	"\x05Range\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\"\x9c\x01\n" +
+	"\x13CompletionCandidate\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\x12&\n" +
+	"\x05range\x18\x02 \x01(\v2\x10.assistant.RangeR\x05range\x12\x14\n" +
+	"\x05score\x18\x03 \x01(\x02R\x05score\x12-\n" +
+	"\x04kind\x18\x04 \x01(\x0e2\x19.assistant.CompletionKindR\x04kind\"\x8f\x04\n" +
+	"\x0eStreamResponse\x12\x1c\n" +
+	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x0e\n" +
+	"\x02id\x18\x04 \x01(\tR\x02id\x12+\n" +
+	"\x04text\x18\x05 \x01(\v2\x15.assistant.TokenDeltaH\x00R\x04text\x129\n" +
+	"\treasoning\x18\x06 \x01(\v2\x19.assistant.ReasoningDeltaH\x00R\treasoning\x128\n" +
+	"\btoolCall\x18\a \x01(\v2\x1a.assistant.ToolCallStartedH\x00R\btoolCall\x12;\n" +
+	"\n" +
+	"toolResult\x18\b \x01(\v2\x19.assistant.ToolCallResultH\x00R\n" +
+	"toolResult\x121\n" +
+	"\bcitation\x18\t \x01(\v2\x13.assistant.CitationH\x00R\bcitation\x12(\n" +
+	"\x05usage\x18\n" +
+	" \x01(\v2\x10.assistant.UsageH\x00R\x05usage\x12.\n" +
+	"\x05final\x18\v \x01(\v2\x16.assistant.StreamFinalH\x00R\x05final\x12.\n" +
+	"\x05error\x18\f \x01(\v2\x16.assistant.StreamErrorH\x00R\x05errorB\a\n" +
+	"\x05event\"*\n" +
+	"\x0eReasoningDelta\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"n\n" +
+	"\bCitation\x12\x10\n" +
+	"\x03uri\x18\x01 \x01(\tR\x03uri\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1e\n" +
+	"\n" +
+	"startIndex\x18\x03 \x01(\x05R\n" +
+	"startIndex\x12\x1a\n" +
+	"\bendIndex\x18\x04 \x01(\x05R\bendIndex\"\x9d\x01\n" +
+	"\x05Usage\x12\"\n" +
+	"\fpromptTokens\x18\x01 \x01(\x05R\fpromptTokens\x12*\n" +
+	"\x10completionTokens\x18\x02 \x01(\x05R\x10completionTokens\x12 \n" +
+	"\vtotalTokens\x18\x03 \x01(\x05R\vtotalTokens\x12\"\n" +
+	"\fcachedTokens\x18\x04 \x01(\x05R\fcachedTokens\"J\n" +
+	"\vStreamFinal\x12;\n" +
+	"\ffinishReason\x18\x01 \x01(\x0e2\x17.assistant.FinishReasonR\ffinishReason\";\n" +
+	"\vStreamError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xa4\x02\n" +
+	"\x0eSessionRequest\x12/\n" +
+	"\x05start\x18\x01 \x01(\v2\x17.assistant.SessionStartH\x00R\x05start\x12,\n" +
+	"\x04turn\x18\x02 \x01(\v2\x16.assistant.SessionTurnH\x00R\x04turn\x12/\n" +
+	"\x06cancel\x18\x03 \x01(\v2\x15.assistant.CancelTurnH\x00R\x06cancel\x12=\n" +
+	"\ftoolDecision\x18\x04 \x01(\v2\x17.assistant.ToolDecisionH\x00R\ftoolDecision\x128\n" +
+	"\bsettings\x18\x05 \x01(\v2\x1a.assistant.SessionSettingsH\x00R\bsettingsB\t\n" +
+	"\arequest\"\x82\x01\n" +
+	"\fSessionStart\x12\x1c\n" +
+	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x1c\n" +
+	"\tworkspace\x18\x02 \x01(\tR\tworkspace\x126\n" +
+	"\bsettings\x18\x03 \x01(\v2\x1a.assistant.SessionSettingsR\bsettings\"U\n" +
+	"\x0fSessionSettings\x12\x16\n" +
+	"\x06plugin\x18\x01 \x01(\tR\x06plugin\x12*\n" +
+	"\x10autoApproveTools\x18\x02 \x01(\bR\x10autoApproveTools\"\x8f\x01\n" +
+	"\vSessionTurn\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x05R\x03seq\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1c\n" +
+	"\tfrontPart\x18\x03 \x01(\tR\tfrontPart\x12\x1a\n" +
+	"\bbackPart\x18\x04 \x01(\tR\bbackPart\x12\x1a\n" +
+	"\bfilename\x18\x05 \x01(\tR\bfilename\"\x1e\n" +
+	"\n" +
+	"CancelTurn\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x05R\x03seq\"B\n" +
+	"\fToolDecision\x12\x16\n" +
+	"\x06callId\x18\x01 \x01(\tR\x06callId\x12\x1a\n" +
+	"\bapproved\x18\x02 \x01(\bR\bapproved\"\xee\x04\n" +
+	"\fSessionEvent\x12\x1c\n" +
+	"\tsessionId\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12:\n" +
+	"\vturnStarted\x18\x03 \x01(\v2\x16.assistant.TurnStartedH\x00R\vturnStarted\x12-\n" +
+	"\x05token\x18\x04 \x01(\v2\x15.assistant.TokenDeltaH\x00R\x05token\x12D\n" +
+	"\ftoolApproval\x18\x05 \x01(\v2\x1e.assistant.ToolApprovalRequestH\x00R\ftoolApproval\x128\n" +
+	"\btoolCall\x18\x06 \x01(\v2\x1a.assistant.ToolCallStartedH\x00R\btoolCall\x12;\n" +
+	"\n" +
+	"toolResult\x18\a \x01(\v2\x19.assistant.ToolCallResultH\x00R\n" +
+	"toolResult\x124\n" +
+	"\tturnEnded\x18\b \x01(\v2\x14.assistant.TurnEndedH\x00R\tturnEnded\x12/\n" +
+	"\x05error\x18\t \x01(\v2\x17.assistant.SessionErrorH\x00R\x05error\x129\n" +
+	"\treasoning\x18\n" +
+	" \x01(\v2\x19.assistant.ReasoningDeltaH\x00R\treasoning\x121\n" +
+	"\bcitation\x18\v \x01(\v2\x13.assistant.CitationH\x00R\bcitation\x12(\n" +
+	"\x05usage\x18\f \x01(\v2\x10.assistant.UsageH\x00R\x05usageB\a\n" +
+	"\x05event\"\r\n" +
+	"\vTurnStarted\"&\n" +
+	"\n" +
+	"TokenDelta\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"L\n" +
+	"\bToolCall\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\targuments\x18\x03 \x01(\tR\targuments\"V\n" +
+	"\x13ToolApprovalRequest\x12\x16\n" +
+	"\x06callId\x18\x01 \x01(\tR\x06callId\x12'\n" +
+	"\x04call\x18\x02 \x01(\v2\x13.assistant.ToolCallR\x04call\":\n" +
+	"\x0fToolCallStarted\x12'\n" +
+	"\x04call\x18\x01 \x01(\v2\x13.assistant.ToolCallR\x04call\"i\n" +
+	"\x0eToolCallResult\x12'\n" +
+	"\x04call\x18\x01 \x01(\v2\x13.assistant.ToolCallR\x04call\x12\x16\n" +
+	"\x06result\x18\x02 \x01(\tR\x06result\x12\x16\n" +
+	"\x06denied\x18\x03 \x01(\bR\x06denied\"k\n" +
+	"\tTurnEnded\x120\n" +
+	"\x06reason\x18\x01 \x01(\x0e2\x18.assistant.TurnEndReasonR\x06reason\x12\x16\n" +
+	"\x06plugin\x18\x02 \x01(\tR\x06plugin\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"(\n" +
+	"\fSessionError\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage*r\n" +
+	"\x0eCompletionKind\x12\x1f\n" +
+	"\x1bCOMPLETION_KIND_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bCOMPLETION_KIND_SINGLE_LINE\x10\x01\x12\x1e\n" +
+	"\x1aCOMPLETION_KIND_MULTI_LINE\x10\x02*\x81\x01\n" +
+	"\fFinishReason\x12\x1d\n" +
+	"\x19FINISH_REASON_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12FINISH_REASON_STOP\x10\x01\x12\x18\n" +
+	"\x14FINISH_REASON_LENGTH\x10\x02\x12 \n" +
+	"\x1cFINISH_REASON_CONTENT_FILTER\x10\x03*\x8a\x01\n" +
+	"\rTurnEndReason\x12\x1f\n" +
+	"\x1bTURN_END_REASON_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19TURN_END_REASON_COMPLETED\x10\x01\x12\x1d\n" +
+	"\x19TURN_END_REASON_CANCELLED\x10\x02\x12\x1a\n" +
+	"\x16TURN_END_REASON_FAILED\x10\x032\xa1\x02\n" +
+	"\x0eCopilotService\x12;\n" +
+	"\x04Chat\x12\x16.assistant.UserRequest\x1a\x19.assistant.StreamResponse0\x01\x12@\n" +
+	"\fAutoComplete\x12\x16.assistant.UserRequest\x1a\x18.assistant.AgentResponse\x12I\n" +
+	"\x12AutoCompleteStream\x12\x16.assistant.UserRequest\x1a\x19.assistant.StreamResponse0\x01\x12E\n" +
+	"\vChatSession\x12\x19.assistant.SessionRequest\x1a\x17.assistant.SessionEvent(\x010\x01B&Z$github.com/qtopie/homa/gen/assistantb\x06proto3"
+
+var (
+	file_assistant_copilot_proto_rawDescOnce sync.Once
+	file_assistant_copilot_proto_rawDescData []byte
+)
+
+func file_assistant_copilot_proto_rawDescGZIP() []byte {
+	file_assistant_copilot_proto_rawDescOnce.Do(func() {
+		file_assistant_copilot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_assistant_copilot_proto_rawDesc), len(file_assistant_copilot_proto_rawDesc)))
+	})
+	return file_assistant_copilot_proto_rawDescData
+}
+
+var file_assistant_copilot_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_assistant_copilot_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_assistant_copilot_proto_goTypes = []any{
+	(CompletionKind)(0),         // 0: assistant.CompletionKind
+	(FinishReason)(0),           // 1: assistant.FinishReason
+	(TurnEndReason)(0),          // 2: assistant.TurnEndReason
+	(*UserRequest)(nil),         // 3: assistant.UserRequest
+	(*AgentResponse)(nil),       // 4: assistant.AgentResponse
+	(*Range)(nil),               // 5: assistant.Range
+	(*CompletionCandidate)(nil), // 6: assistant.CompletionCandidate
+	(*StreamResponse)(nil),      // 7: assistant.StreamResponse
+	(*ReasoningDelta)(nil),      // 8: assistant.ReasoningDelta
+	(*Citation)(nil),            // 9: assistant.Citation
+	(*Usage)(nil),               // 10: assistant.Usage
+	(*StreamFinal)(nil),         // 11: assistant.StreamFinal
+	(*StreamError)(nil),         // 12: assistant.StreamError
+	(*SessionRequest)(nil),      // 13: assistant.SessionRequest
+	(*SessionStart)(nil),        // 14: assistant.SessionStart
+	(*SessionSettings)(nil),     // 15: assistant.SessionSettings
+	(*SessionTurn)(nil),         // 16: assistant.SessionTurn
+	(*CancelTurn)(nil),          // 17: assistant.CancelTurn
+	(*ToolDecision)(nil),        // 18: assistant.ToolDecision
+	(*SessionEvent)(nil),        // 19: assistant.SessionEvent
+	(*TurnStarted)(nil),         // 20: assistant.TurnStarted
+	(*TokenDelta)(nil),          // 21: assistant.TokenDelta
+	(*ToolCall)(nil),            // 22: assistant.ToolCall
+	(*ToolApprovalRequest)(nil), // 23: assistant.ToolApprovalRequest
+	(*ToolCallStarted)(nil),     // 24: assistant.ToolCallStarted
+	(*ToolCallResult)(nil),      // 25: assistant.ToolCallResult
+	(*TurnEnded)(nil),           // 26: assistant.TurnEnded
+	(*SessionError)(nil),        // 27: assistant.SessionError
+}
+var file_assistant_copilot_proto_depIdxs = []int32{
+	6,  // 0: assistant.AgentResponse.candidates:type_name -> assistant.CompletionCandidate
+	5,  // 1: assistant.CompletionCandidate.range:type_name -> assistant.Range
+	0,  // 2: assistant.CompletionCandidate.kind:type_name -> assistant.CompletionKind
+	21, // 3: assistant.StreamResponse.text:type_name -> assistant.TokenDelta
+	8,  // 4: assistant.StreamResponse.reasoning:type_name -> assistant.ReasoningDelta
+	24, // 5: assistant.StreamResponse.toolCall:type_name -> assistant.ToolCallStarted
+	25, // 6: assistant.StreamResponse.toolResult:type_name -> assistant.ToolCallResult
+	9,  // 7: assistant.StreamResponse.citation:type_name -> assistant.Citation
+	10, // 8: assistant.StreamResponse.usage:type_name -> assistant.Usage
+	11, // 9: assistant.StreamResponse.final:type_name -> assistant.StreamFinal
+	12, // 10: assistant.StreamResponse.error:type_name -> assistant.StreamError
+	1,  // 11: assistant.StreamFinal.finishReason:type_name -> assistant.FinishReason
+	14, // 12: assistant.SessionRequest.start:type_name -> assistant.SessionStart
+	16, // 13: assistant.SessionRequest.turn:type_name -> assistant.SessionTurn
+	17, // 14: assistant.SessionRequest.cancel:type_name -> assistant.CancelTurn
+	18, // 15: assistant.SessionRequest.toolDecision:type_name -> assistant.ToolDecision
+	15, // 16: assistant.SessionRequest.settings:type_name -> assistant.SessionSettings
+	15, // 17: assistant.SessionStart.settings:type_name -> assistant.SessionSettings
+	20, // 18: assistant.SessionEvent.turnStarted:type_name -> assistant.TurnStarted
+	21, // 19: assistant.SessionEvent.token:type_name -> assistant.TokenDelta
+	23, // 20: assistant.SessionEvent.toolApproval:type_name -> assistant.ToolApprovalRequest
+	24, // 21: assistant.SessionEvent.toolCall:type_name -> assistant.ToolCallStarted
+	25, // 22: assistant.SessionEvent.toolResult:type_name -> assistant.ToolCallResult
+	26, // 23: assistant.SessionEvent.turnEnded:type_name -> assistant.TurnEnded
+	27, // 24: assistant.SessionEvent.error:type_name -> assistant.SessionError
+	8,  // 25: assistant.SessionEvent.reasoning:type_name -> assistant.ReasoningDelta
+	9,  // 26: assistant.SessionEvent.citation:type_name -> assistant.Citation
+	10, // 27: assistant.SessionEvent.usage:type_name -> assistant.Usage
+	22, // 28: assistant.ToolApprovalRequest.call:type_name -> assistant.ToolCall
+	22, // 29: assistant.ToolCallStarted.call:type_name -> assistant.ToolCall
+	22, // 30: assistant.ToolCallResult.call:type_name -> assistant.ToolCall
+	2,  // 31: assistant.TurnEnded.reason:type_name -> assistant.TurnEndReason
+	3,  // 32: assistant.CopilotService.Chat:input_type -> assistant.UserRequest
+	3,  // 33: assistant.CopilotService.AutoComplete:input_type -> assistant.UserRequest
+	3,  // 34: assistant.CopilotService.AutoCompleteStream:input_type -> assistant.UserRequest
+	13, // 35: assistant.CopilotService.ChatSession:input_type -> assistant.SessionRequest
+	7,  // 36: assistant.CopilotService.Chat:output_type -> assistant.StreamResponse
+	4,  // 37: assistant.CopilotService.AutoComplete:output_type -> assistant.AgentResponse
+	7,  // 38: assistant.CopilotService.AutoCompleteStream:output_type -> assistant.StreamResponse
+	19, // 39: assistant.CopilotService.ChatSession:output_type -> assistant.SessionEvent
+	36, // [36:40] is the sub-list for method output_type
+	32, // [32:36] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
+}
+
+func init() { file_assistant_copilot_proto_init() }
+func file_assistant_copilot_proto_init() {
+	if File_assistant_copilot_proto != nil {
+		return
+	}
+	file_assistant_copilot_proto_msgTypes[4].OneofWrappers = []any{
+		(*StreamResponse_Text)(nil),
+		(*StreamResponse_Reasoning)(nil),
+		(*StreamResponse_ToolCall)(nil),
+		(*StreamResponse_ToolResult)(nil),
+		(*StreamResponse_Citation)(nil),
+		(*StreamResponse_Usage)(nil),
+		(*StreamResponse_Final)(nil),
+		(*StreamResponse_Error)(nil),
+	}
+	file_assistant_copilot_proto_msgTypes[10].OneofWrappers = []any{
+		(*SessionRequest_Start)(nil),
+		(*SessionRequest_Turn)(nil),
+		(*SessionRequest_Cancel)(nil),
+		(*SessionRequest_ToolDecision)(nil),
+		(*SessionRequest_Settings)(nil),
+	}
+	file_assistant_copilot_proto_msgTypes[16].OneofWrappers = []any{
+		(*SessionEvent_TurnStarted)(nil),
+		(*SessionEvent_Token)(nil),
+		(*SessionEvent_ToolApproval)(nil),
+		(*SessionEvent_ToolCall)(nil),
+		(*SessionEvent_ToolResult)(nil),
+		(*SessionEvent_TurnEnded)(nil),
+		(*SessionEvent_Error)(nil),
+		(*SessionEvent_Reasoning)(nil),
+		(*SessionEvent_Citation)(nil),
+		(*SessionEvent_Usage)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_assistant_copilot_proto_rawDesc), len(file_assistant_copilot_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      3,
+			NumMessages:   25,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_assistant_copilot_proto_goTypes,
 		DependencyIndexes: file_assistant_copilot_proto_depIdxs,
+		EnumInfos:         file_assistant_copilot_proto_enumTypes,
 		MessageInfos:      file_assistant_copilot_proto_msgTypes,
 	}.Build()
 	File_assistant_copilot_proto = out.File
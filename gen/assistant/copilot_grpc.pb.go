@@ -19,8 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CopilotService_Chat_FullMethodName         = "/assistant.CopilotService/Chat"
-	CopilotService_AutoComplete_FullMethodName = "/assistant.CopilotService/AutoComplete"
+	CopilotService_Chat_FullMethodName               = "/assistant.CopilotService/Chat"
+	CopilotService_AutoComplete_FullMethodName       = "/assistant.CopilotService/AutoComplete"
+	CopilotService_AutoCompleteStream_FullMethodName = "/assistant.CopilotService/AutoCompleteStream"
+	CopilotService_ChatSession_FullMethodName        = "/assistant.CopilotService/ChatSession"
 )
 
 // CopilotServiceClient is the client API for CopilotService service.
@@ -31,6 +33,14 @@ const (
 type CopilotServiceClient interface {
 	Chat(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamResponse], error)
 	AutoComplete(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AgentResponse, error)
+	// AutoCompleteStream sends the completion in parts as it is generated;
+	// each response's content continues the previous ones.
+	AutoCompleteStream(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamResponse], error)
+	// ChatSession keeps one stream open per chat session. The client starts it
+	// with a SessionStart, then sends turns, cancellations, tool decisions and
+	// settings; the server streams the events of each turn back. History and
+	// workspace are kept by the server, so turns only carry what changed.
+	ChatSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SessionRequest, SessionEvent], error)
 }
 
 type copilotServiceClient struct {
@@ -70,6 +80,38 @@ func (c *copilotServiceClient) AutoComplete(ctx context.Context, in *UserRequest
 	return out, nil
 }
 
+func (c *copilotServiceClient) AutoCompleteStream(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CopilotService_ServiceDesc.Streams[1], CopilotService_AutoCompleteStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UserRequest, StreamResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CopilotService_AutoCompleteStreamClient = grpc.ServerStreamingClient[StreamResponse]
+
+func (c *copilotServiceClient) ChatSession(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SessionRequest, SessionEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CopilotService_ServiceDesc.Streams[2], CopilotService_ChatSession_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SessionRequest, SessionEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CopilotService_ChatSessionClient = grpc.BidiStreamingClient[SessionRequest, SessionEvent]
+
 // CopilotServiceServer is the server API for CopilotService service.
 // All implementations must embed UnimplementedCopilotServiceServer
 // for forward compatibility.
@@ -78,6 +120,14 @@ func (c *copilotServiceClient) AutoComplete(ctx context.Context, in *UserRequest
 type CopilotServiceServer interface {
 	Chat(*UserRequest, grpc.ServerStreamingServer[StreamResponse]) error
 	AutoComplete(context.Context, *UserRequest) (*AgentResponse, error)
+	// AutoCompleteStream sends the completion in parts as it is generated;
+	// each response's content continues the previous ones.
+	AutoCompleteStream(*UserRequest, grpc.ServerStreamingServer[StreamResponse]) error
+	// ChatSession keeps one stream open per chat session. The client starts it
+	// with a SessionStart, then sends turns, cancellations, tool decisions and
+	// settings; the server streams the events of each turn back. History and
+	// workspace are kept by the server, so turns only carry what changed.
+	ChatSession(grpc.BidiStreamingServer[SessionRequest, SessionEvent]) error
 	mustEmbedUnimplementedCopilotServiceServer()
 }
 
@@ -94,6 +144,12 @@ func (UnimplementedCopilotServiceServer) Chat(*UserRequest, grpc.ServerStreaming
 func (UnimplementedCopilotServiceServer) AutoComplete(context.Context, *UserRequest) (*AgentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AutoComplete not implemented")
 }
+func (UnimplementedCopilotServiceServer) AutoCompleteStream(*UserRequest, grpc.ServerStreamingServer[StreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method AutoCompleteStream not implemented")
+}
+func (UnimplementedCopilotServiceServer) ChatSession(grpc.BidiStreamingServer[SessionRequest, SessionEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method ChatSession not implemented")
+}
 func (UnimplementedCopilotServiceServer) mustEmbedUnimplementedCopilotServiceServer() {}
 func (UnimplementedCopilotServiceServer) testEmbeddedByValue()                        {}
 
@@ -144,6 +200,24 @@ func _CopilotService_AutoComplete_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CopilotService_AutoCompleteStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(UserRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CopilotServiceServer).AutoCompleteStream(m, &grpc.GenericServerStream[UserRequest, StreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CopilotService_AutoCompleteStreamServer = grpc.ServerStreamingServer[StreamResponse]
+
+func _CopilotService_ChatSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CopilotServiceServer).ChatSession(&grpc.GenericServerStream[SessionRequest, SessionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CopilotService_ChatSessionServer = grpc.BidiStreamingServer[SessionRequest, SessionEvent]
+
 // CopilotService_ServiceDesc is the grpc.ServiceDesc for CopilotService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -162,6 +236,17 @@ var CopilotService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CopilotService_Chat_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "AutoCompleteStream",
+			Handler:       _CopilotService_AutoCompleteStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ChatSession",
+			Handler:       _CopilotService_ChatSession_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "assistant/copilot.proto",
 }
@@ -2,34 +2,104 @@ package config
 
 import (
 	"log"
+	"sync"
+	"sync/atomic"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-viper/encoding/ini"
 	"github.com/spf13/viper"
 )
 
 var (
-	viperCfg *viper.Viper
+	// current is the config requests read. A reload parses the file into a
+	// new instance and swaps it in, so readers never see viper being written.
+	current atomic.Pointer[viper.Viper]
+
+	hooksMu sync.Mutex
+	hooks   []func()
 )
 
 func init() {
-	viper.GetViper().AllKeys()
-	// Configure Viper to read the config file
+	// Empty until Load
+	current.Store(newViper())
+}
+
+// Load reads config.ini from the working directory or $HOME/.cosmos.
+func Load() error {
+	v := newViper()
+	v.SetConfigName("config")
+	v.AddConfigPath(".")
+	v.AddConfigPath("$HOME/.cosmos")
+	return load(v)
+}
+
+// LoadFile reads the config from file.
+func LoadFile(file string) error {
+	v := newViper()
+	v.SetConfigFile(file)
+	return load(v)
+}
+
+func load(v *viper.Viper) error {
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	current.Store(v)
+	return nil
+}
+
+// newViper returns an empty viper instance reading ini files.
+func newViper() *viper.Viper {
 	codecRegistry := viper.NewCodecRegistry()
 	codecRegistry.RegisterCodec("ini", ini.Codec{})
 
-	viperCfg = viper.NewWithOptions(
+	v := viper.NewWithOptions(
 		viper.WithCodecRegistry(codecRegistry),
 	)
-
-	viperCfg.SetConfigName("config")
-	viperCfg.SetConfigType("ini")
-	viperCfg.AddConfigPath(".")
-	viperCfg.AddConfigPath("$HOME/.cosmos")
-	if err := viperCfg.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file: %v", err)
-	}
+	v.SetConfigType("ini")
+	return v
 }
 
+// GetAppConfig returns the current config. It is replaced, not modified, when
+// the file changes, so callers must not modify it either.
 func GetAppConfig() *viper.Viper {
-	return viperCfg
+	return current.Load()
+}
+
+// OnChange registers fn to be called after the config file changes on disk.
+// Hooks only run once WatchConfig has been called.
+func OnChange(fn func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// WatchConfig watches the config file and re-reads it on every change, then
+// runs the hooks registered with OnChange. A file that fails to parse leaves
+// the previous config in place.
+func WatchConfig() {
+	file := current.Load().ConfigFileUsed()
+	if file == "" {
+		return
+	}
+
+	// The watcher re-reads the file into its own instance, which nothing
+	// else reads
+	watcher := newViper()
+	watcher.SetConfigFile(file)
+	watcher.OnConfigChange(func(e fsnotify.Event) {
+		log.Printf("Config file changed: %s", e.Name)
+		if err := LoadFile(file); err != nil {
+			log.Printf("Error reading config file, keeping the previous config: %v", err)
+			return
+		}
+
+		hooksMu.Lock()
+		fns := append([]func(){}, hooks...)
+		hooksMu.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	})
+	watcher.WatchConfig()
 }
@@ -0,0 +1,110 @@
+package completion
+
+import "strings"
+
+// Format is a model family's fill-in-the-middle prompt format.
+type Format struct {
+	Name   string
+	Prefix string // token before the code before the cursor
+	Suffix string // token before the code after the cursor
+	Middle string // token after which the model generates the middle
+
+	// SuffixFirst puts the code after the cursor before the code before it
+	SuffixFirst bool
+
+	// Stop are the tokens ending a completion
+	Stop []string
+}
+
+var formats = []*Format{
+	{
+		Name:   "codellama",
+		Prefix: "<PRE> ", Suffix: " <SUF>", Middle: " <MID>",
+		Stop: []string{"<EOT>"},
+	},
+	{
+		Name:   "starcoder",
+		Prefix: "<fim_prefix>", Suffix: "<fim_suffix>", Middle: "<fim_middle>",
+		Stop: []string{"<|endoftext|>", "<file_sep>"},
+	},
+	{
+		Name:   "deepseek",
+		Prefix: "<｜fim▁begin｜>", Suffix: "<｜fim▁hole｜>", Middle: "<｜fim▁end｜>",
+		Stop: []string{"<｜end▁of▁sentence｜>", "<|EOT|>"},
+	},
+	{
+		Name:   "qwen",
+		Prefix: "<|fim_prefix|>", Suffix: "<|fim_suffix|>", Middle: "<|fim_middle|>",
+		Stop: []string{"<|endoftext|>", "<|fim_pad|>", "<|file_sep|>", "<|repo_name|>", "<|im_start|>"},
+	},
+	{
+		Name:   "codegemma",
+		Prefix: "<|fim_prefix|>", Suffix: "<|fim_suffix|>", Middle: "<|fim_middle|>",
+		Stop: []string{"<|file_separator|>", "<end_of_turn>", "<eos>"},
+	},
+	{
+		Name:   "codestral",
+		Prefix: "[PREFIX]", Suffix: "[SUFFIX]",
+		SuffixFirst: true,
+		Stop:        []string{"</s>"},
+	},
+}
+
+// modelFamilies maps substrings of model names to their FIM format.
+var modelFamilies = []struct {
+	match  []string // all must occur in the lowercased model name
+	format string
+}{
+	{[]string{"codellama"}, "codellama"},
+	{[]string{"code-llama"}, "codellama"},
+	{[]string{"starcoder"}, "starcoder"},
+	{[]string{"santacoder"}, "starcoder"},
+	{[]string{"deepseek", "coder"}, "deepseek"},
+	{[]string{"qwen", "coder"}, "qwen"},
+	{[]string{"codegemma"}, "codegemma"},
+	{[]string{"codestral"}, "codestral"},
+}
+
+// LookupFormat returns the FIM format with the given name, or nil.
+func LookupFormat(name string) *Format {
+	for _, f := range formats {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FormatFor guesses the FIM format from a model name. It returns nil for
+// models without known FIM support, which are prompted as chat models.
+func FormatFor(model string) *Format {
+	model = strings.ToLower(model)
+	for _, family := range modelFamilies {
+		matched := true
+		for _, m := range family.match {
+			matched = matched && strings.Contains(model, m)
+		}
+		if matched {
+			return LookupFormat(family.format)
+		}
+	}
+	return nil
+}
+
+// Prompt renders the raw FIM prompt for a completions endpoint.
+func (f *Format) Prompt(in Input) string {
+	if f.SuffixFirst {
+		return f.Suffix + in.Suffix + f.Prefix + in.Prefix + f.Middle
+	}
+	return f.Prefix + in.Prefix + f.Suffix + in.Suffix + f.Middle
+}
+
+// StopSequences returns the stop sequences for completing in: the format's
+// end tokens, plus the end of the line when only the line is completed.
+func (f *Format) StopSequences(in Input) []string {
+	stop := append([]string(nil), f.Stop...)
+	if in.SingleLine() {
+		stop = append(stop, "\n")
+	}
+	return stop
+}
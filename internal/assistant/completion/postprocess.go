@@ -0,0 +1,179 @@
+package completion
+
+import "strings"
+
+// PostProcess turns raw model output into the text to insert at the cursor:
+// it strips Markdown fences and echoed context, and cuts the completion at the
+// end of the line or block the cursor is in.
+func PostProcess(in Input, out string) string {
+	out, _ = postProcess(in, out)
+	return out
+}
+
+// postProcess is PostProcess that also reports whether the output was cut at
+// the end of the line or block, so that anything generated after it is moot.
+func postProcess(in Input, out string) (string, bool) {
+	out = stripFences(out)
+	out = strings.ReplaceAll(out, CursorMarker, "")
+	out = trimPrefixEcho(in.Prefix, out)
+
+	var cut bool
+	if in.SingleLine() {
+		out, _, cut = strings.Cut(out, "\n")
+	} else {
+		out, cut = cutAtBlockEnd(in, out)
+	}
+	out = trimSuffixOverlap(in.Suffix, out)
+	return strings.TrimRight(out, " \t\r\n"), cut
+}
+
+// stripFences removes a Markdown code fence the model added despite being
+// told not to.
+func stripFences(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "```") {
+		return s
+	}
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	if i := strings.IndexByte(trimmed, '\n'); i >= 0 {
+		trimmed = trimmed[i+1:] // language tag
+	} else {
+		trimmed = ""
+	}
+	trimmed = strings.TrimSuffix(strings.TrimRight(trimmed, "\n"), "```")
+	return strings.TrimSuffix(trimmed, "\n")
+}
+
+// trimPrefixEcho drops the start of the output when the model repeated the
+// current line (or more) of the code before the cursor.
+func trimPrefixEcho(prefix, out string) string {
+	line := prefix[strings.LastIndexByte(prefix, '\n')+1:]
+	if strings.TrimSpace(line) == "" {
+		return out
+	}
+	if strings.HasPrefix(out, line) {
+		return out[len(line):]
+	}
+	// the model may also have dropped the indentation of the echoed line
+	if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(out, trimmed) {
+		return out[len(trimmed):]
+	}
+	return out
+}
+
+// trimSuffixOverlap drops the end of the output that repeats the start of the
+// code after the cursor, such as a closing bracket that is already there.
+// Short overlaps only count when they are closing punctuation, so that a
+// completion ending in the same letter as the suffix starts is left alone.
+func trimSuffixOverlap(suffix, out string) string {
+	suffix = strings.TrimLeft(suffix, " \t")
+	trimmed := strings.TrimRight(out, " \t\r\n")
+	for n := min(len(trimmed), len(suffix)); n > 0; n-- {
+		overlap := strings.TrimSpace(suffix[:n])
+		if overlap == "" || !strings.HasSuffix(trimmed, suffix[:n]) {
+			continue
+		}
+		if len(overlap) >= 3 || strings.Trim(overlap, ")]}'\";,") == "" {
+			return trimmed[:len(trimmed)-n]
+		}
+	}
+	return out
+}
+
+// cutAtBlockEnd ends a multi-line completion where the block the cursor is in
+// ends: at a line indented less than the cursor line, at a line back at the
+// cursor line's indentation after the completion went deeper, or before a
+// line that is the next line of the code after the cursor.
+func cutAtBlockEnd(in Input, out string) (string, bool) {
+	curLine := in.Prefix[strings.LastIndexByte(in.Prefix, '\n')+1:]
+	base := indentWidth(curLine)
+	if curLine == "" {
+		// at the start of an empty line the block is the one opened or
+		// continued by the line above
+		prev := lastNonBlankLine(in.Prefix)
+		base = indentWidth(prev)
+		if opensBlock(prev) {
+			base++
+		}
+	}
+	nextSuffix := firstNonBlankLine(in.Suffix)
+
+	lines := strings.Split(out, "\n")
+	// completing a statement that is already begun ends with it
+	deeper := strings.TrimSpace(curLine) != ""
+	for i, line := range lines {
+		if i == 0 {
+			// the first line continues the cursor line
+			deeper = deeper || opensBlock(curLine+line)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if nextSuffix != "" && strings.TrimRight(line, " \t\r") == nextSuffix {
+			return strings.Join(lines[:i], "\n"), true
+		}
+		width := indentWidth(line)
+		switch {
+		case width < base:
+			if closesBlock(line) && !strings.HasPrefix(strings.TrimSpace(in.Suffix), strings.TrimSpace(line)) {
+				return strings.Join(lines[:i+1], "\n"), true
+			}
+			return strings.Join(lines[:i], "\n"), true
+		case width > base:
+			deeper = true
+		case deeper && !closesBlock(line):
+			// back at the cursor's level after a nested block: a new statement
+			// the user did not ask for
+			return strings.Join(lines[:i], "\n"), true
+		}
+	}
+	return out, false
+}
+
+func indentWidth(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func opensBlock(line string) bool {
+	line = strings.TrimRight(line, " \t\r")
+	return strings.HasSuffix(line, "{") || strings.HasSuffix(line, "(") ||
+		strings.HasSuffix(line, "[") || strings.HasSuffix(line, ":")
+}
+
+func closesBlock(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && strings.ContainsAny(line[:1], "}])")
+}
+
+func lastNonBlankLine(s string) string {
+	lines := strings.Split(s, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return lines[i]
+		}
+	}
+	return ""
+}
+
+// firstNonBlankLine returns the first line of s with text, with its
+// indentation.
+func firstNonBlankLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return strings.TrimRight(line, " \t\r")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package completion
+
+import "testing"
+
+func TestPostProcess(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		suffix string
+		out    string
+		want   string
+	}{
+		{
+			name:   "strips code fences",
+			prefix: "x := ",
+			suffix: "\n",
+			out:    "```go\nfoo()\n```",
+			want:   "foo()",
+		},
+		{
+			name:   "removes the cursor marker",
+			prefix: "a := ",
+			suffix: "\n",
+			out:    "<CURSOR>1",
+			want:   "1",
+		},
+		{
+			name:   "drops an echoed line",
+			prefix: "func main() {\n\tfmt.Print",
+			suffix: "\n}",
+			out:    "\tfmt.Println(\"hi\")",
+			want:   "ln(\"hi\")",
+		},
+		{
+			name:   "drops an echoed line without its indentation",
+			prefix: "func main() {\n\tfmt.Print",
+			suffix: "\n}",
+			out:    "fmt.Println()",
+			want:   "ln()",
+		},
+		{
+			name:   "completes only the rest of the line mid-line",
+			prefix: "x := compute(",
+			suffix: "a, b)\nreturn x",
+			out:    "ctx, \nmore()",
+			want:   "ctx,",
+		},
+		{
+			name:   "drops a closing bracket already after the cursor",
+			prefix: "foo(",
+			suffix: ")\n",
+			out:    "bar)",
+			want:   "bar",
+		},
+		{
+			name:   "keeps a short overlap that is not punctuation",
+			prefix: "x = ",
+			suffix: "y\n",
+			out:    "xy",
+			want:   "xy",
+		},
+		{
+			name:   "stops before the next line of the suffix",
+			prefix: "func f() {\n\tif x {\n\t\t",
+			suffix: "\n\t}\n}",
+			out:    "return 1\n\t}\n\treturn 2\n}",
+			want:   "return 1",
+		},
+		{
+			name:   "stops back at the cursor's level after a nested block",
+			prefix: "func main() {\n\t",
+			suffix: "\n}\n",
+			out:    "for i := range 3 {\n\t\tfmt.Println(i)\n\t}\n\tfmt.Println(\"done\")",
+			want:   "for i := range 3 {\n\t\tfmt.Println(i)\n\t}",
+		},
+		{
+			name:   "keeps a closing brace missing from the suffix",
+			prefix: "if x {\n\t",
+			out:    "y()\n}\nz()",
+			want:   "y()\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := Input{Prefix: tt.prefix, Suffix: tt.suffix}
+			if got := PostProcess(in, tt.out); got != tt.want {
+				t.Errorf("PostProcess() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamerMatchesPostProcess(t *testing.T) {
+	in := Input{Prefix: "func main() {\n\t", Suffix: "\n}\n"}
+	out := "for i := range 3 {\n\t\tfmt.Println(i)\n\t}\n\tfmt.Println(\"done\")\n"
+
+	s := NewStreamer(in)
+	var got string
+	done := false
+	for i := 0; i < len(out) && !done; i += 5 {
+		var delta string
+		delta, done = s.Write(out[i:min(i+5, len(out))])
+		got += delta
+	}
+	if !done {
+		got += s.Flush()
+	}
+	if want := PostProcess(in, out); got != want {
+		t.Errorf("streamed %q, want %q", got, want)
+	}
+	if !done {
+		t.Error("streamer did not stop at the end of the block")
+	}
+}
@@ -0,0 +1,140 @@
+// Package completion builds code completion prompts from the text around the
+// cursor and cleans up what models return, for chat models as well as models
+// with native fill-in-the-middle (FIM) tokens.
+package completion
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+// Context sent to the model is cut at line boundaries to these sizes, keeping
+// the text nearest to the cursor.
+const (
+	MaxPrefixChars = 12000
+	MaxSuffixChars = 4000
+)
+
+// CursorMarker marks the insertion point in prompts for chat models.
+const CursorMarker = "<CURSOR>"
+
+// Input is the text around the cursor.
+type Input struct {
+	Prefix    string
+	Suffix    string
+	Filename  string
+	Workspace string
+}
+
+// NewInput takes the cursor context from a copilot request.
+func NewInput(req shared.UserRequest) Input {
+	return Input{
+		Prefix:    truncatePrefix(req.FrontPart, MaxPrefixChars),
+		Suffix:    truncateSuffix(req.BackPart, MaxSuffixChars),
+		Filename:  req.Filename,
+		Workspace: req.Workspace,
+	}
+}
+
+func truncatePrefix(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	s = s[len(s)-limit:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func truncateSuffix(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	s = s[:limit]
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+// Empty reports whether there is no code around the cursor.
+func (in Input) Empty() bool {
+	return strings.TrimSpace(in.Prefix) == "" && strings.TrimSpace(in.Suffix) == ""
+}
+
+// SingleLine reports whether the cursor is in the middle of a line, where
+// only the rest of that line should be completed. Closing brackets after the
+// cursor, as editors insert them, do not count.
+func (in Input) SingleLine() bool {
+	rest, _, _ := strings.Cut(in.Suffix, "\n")
+	return strings.Trim(rest, " \t\r)]};") != ""
+}
+
+// Path returns the file name relative to the workspace when it lies inside it.
+func (in Input) Path() string {
+	if in.Workspace != "" {
+		if rel, err := filepath.Rel(in.Workspace, in.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return in.Filename
+}
+
+// Language guesses the programming language from the file extension.
+func (in Input) Language() string {
+	return languages[strings.ToLower(filepath.Ext(in.Filename))]
+}
+
+var languages = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rs":    "Rust",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".swift": "Swift",
+	".scala": "Scala",
+	".sh":    "Shell",
+	".sql":   "SQL",
+	".proto": "Protocol Buffers",
+	".md":    "Markdown",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".json":  "JSON",
+}
+
+const chatSystemPrompt = `You are a code completion engine. You receive a source file in which the cursor position is marked as ` + CursorMarker + `.
+Reply with only the code to insert at the cursor so that it connects the code before and after it.
+Do not repeat code that is already there, do not add explanations and do not wrap the code in Markdown fences.`
+
+// ChatPrompt renders the system instruction and the user message for a chat
+// model without native FIM support.
+func ChatPrompt(in Input) (system, user string) {
+	var b strings.Builder
+	if path := in.Path(); path != "" {
+		fmt.Fprintf(&b, "File: %s\n", path)
+	}
+	if lang := in.Language(); lang != "" {
+		fmt.Fprintf(&b, "Language: %s\n", lang)
+	}
+	if in.SingleLine() {
+		b.WriteString("Complete only the rest of the current line.\n")
+	}
+	fmt.Fprintf(&b, "\n%s%s%s", in.Prefix, CursorMarker, in.Suffix)
+	return chatSystemPrompt, b.String()
+}
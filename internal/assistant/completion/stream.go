@@ -0,0 +1,58 @@
+package completion
+
+import "strings"
+
+// Streamer post-processes a completion while it is being generated. Text is
+// released line by line, once it is known not to be cut, so that what has
+// been shown is always the start of the final completion.
+type Streamer struct {
+	in   Input
+	raw  strings.Builder
+	sent string
+	done bool
+}
+
+// NewStreamer creates a Streamer for completing in.
+func NewStreamer(in Input) *Streamer {
+	return &Streamer{in: in}
+}
+
+// Write adds generated text. It returns the text that can be shown now, and
+// done once the completion has reached the end of its line or block and the
+// generation can be stopped.
+func (s *Streamer) Write(text string) (delta string, done bool) {
+	if s.done {
+		return "", true
+	}
+	s.raw.WriteString(text)
+	raw := s.raw.String()
+	complete := raw[:strings.LastIndexByte(raw, '\n')+1]
+	if complete == "" {
+		return "", false
+	}
+
+	out, cut := postProcess(s.in, complete)
+	s.done = cut
+	return s.advance(out), cut
+}
+
+// Flush returns the rest of the completion once generation has ended.
+func (s *Streamer) Flush() string {
+	if s.done {
+		return ""
+	}
+	s.done = true
+	out, _ := postProcess(s.in, s.raw.String())
+	return s.advance(out)
+}
+
+// advance returns what out adds to the text sent so far.
+func (s *Streamer) advance(out string) string {
+	if !strings.HasPrefix(out, s.sent) {
+		// A later line changed how earlier ones are read; keep what was sent
+		return ""
+	}
+	delta := out[len(s.sent):]
+	s.sent = out
+	return delta
+}
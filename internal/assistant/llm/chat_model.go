@@ -1,9 +1,13 @@
 package llm
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cloudwego/eino/callbacks"
@@ -11,7 +15,17 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	defaultTimeout = 60 * time.Second
+
+	// 重试间隔从retryBaseDelay开始指数增长
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
 // ChatModel实现eino/components/model/interface.go接口
+// 通过OpenAI兼容的chat completions协议访问模型服务
 type HomaChatModel struct {
 	client     *http.Client
 	apiKey     string
@@ -19,51 +33,97 @@ type HomaChatModel struct {
 	model      string
 	timeout    time.Duration
 	retryCount int
+	tools      []*schema.ToolInfo
 }
 
+var _ model.ToolCallingChatModel = (*HomaChatModel)(nil)
+
 type HomaChatModelConfig struct {
+	// APIKey 以Bearer token发送；使用默认BaseURL时必填，自建服务可留空
 	APIKey string
+	// BaseURL 例如 http://localhost:11434/v1 (Ollama)、http://localhost:8000/v1 (vLLM)
+	BaseURL string
+	Model   string
+	// Timeout 限制单次请求；流式请求只限制收到响应头之前的时间
+	Timeout time.Duration
+	// RetryCount 是限流、5xx和网络错误时的重试次数
+	RetryCount int
+	HTTPClient *http.Client
 }
 
 type MyChatModelOptions struct {
 	Options    *model.Options
 	RetryCount int
 	Timeout    time.Duration
+	Usage      *schema.TokenUsage
+}
+
+// WithRetryCount 覆盖单次调用的重试次数
+func WithRetryCount(n int) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *MyChatModelOptions) {
+		o.RetryCount = n
+	})
+}
+
+// WithTimeout 覆盖单次调用的超时
+func WithTimeout(d time.Duration) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *MyChatModelOptions) {
+		o.Timeout = d
+	})
+}
+
+// WithUsage 让Complete和CompleteStream在结束时把token用量写入u
+// 流式调用在读完流之后才写入；服务端未报告用量时u保持不变
+func WithUsage(u *schema.TokenUsage) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *MyChatModelOptions) {
+		o.Usage = u
+	})
 }
 
 func NewHomaChatModel(config *HomaChatModelConfig) (*HomaChatModel, error) {
-	if config.APIKey == "" {
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if config.APIKey == "" && baseURL == defaultBaseURL {
 		return nil, errors.New("api key is required")
 	}
+	if config.RetryCount < 0 {
+		return nil, errors.New("retry count must not be negative")
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
 
 	return &HomaChatModel{
-		client: &http.Client{},
-		apiKey: config.APIKey,
+		client:     client,
+		apiKey:     config.APIKey,
+		baseURL:    baseURL,
+		model:      config.Model,
+		timeout:    timeout,
+		retryCount: config.RetryCount,
 	}, nil
 }
 
 func (m *HomaChatModel) Generate(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.Message, error) {
 	// 1. 处理选项
-	options := &MyChatModelOptions{
-		Options: &model.Options{
-			Model: &m.model,
-		},
-		RetryCount: m.retryCount,
-		Timeout:    m.timeout,
-	}
-	options.Options = model.GetCommonOptions(options.Options, opts...)
-	options = model.GetImplSpecificOptions(options, opts...)
+	options := m.getOptions(opts...)
 
 	// 2. 开始生成前的回调
 	ctx = callbacks.OnStart(ctx, &model.CallbackInput{
 		Messages: messages,
-		Config: &model.Config{
-			Model: *options.Options.Model,
-		},
+		Tools:    options.Options.Tools,
+		Config:   callbackConfig(options),
 	})
 
 	// 3. 执行生成逻辑
-	response, err := m.doGenerate(ctx, messages, options)
+	response, usage, err := m.doGenerate(ctx, messages, options)
 
 	// 4. 处理错误和完成回调
 	if err != nil {
@@ -72,7 +132,9 @@ func (m *HomaChatModel) Generate(ctx context.Context, messages []*schema.Message
 	}
 
 	ctx = callbacks.OnEnd(ctx, &model.CallbackOutput{
-		Message: response,
+		Message:    response,
+		Config:     callbackConfig(options),
+		TokenUsage: toCallbackUsage(usage),
 	})
 
 	return response, nil
@@ -80,38 +142,42 @@ func (m *HomaChatModel) Generate(ctx context.Context, messages []*schema.Message
 
 func (m *HomaChatModel) Stream(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
 	// 1. 处理选项
-	options := &MyChatModelOptions{
-		Options: &model.Options{
-			Model: &m.model,
-		},
-		RetryCount: m.retryCount,
-		Timeout:    m.timeout,
-	}
-	options.Options = model.GetCommonOptions(options.Options, opts...)
-	options = model.GetImplSpecificOptions(options, opts...)
+	options := m.getOptions(opts...)
 
 	// 2. 开始流式生成前的回调
 	ctx = callbacks.OnStart(ctx, &model.CallbackInput{
 		Messages: messages,
-		Config: &model.Config{
-			Model: *options.Options.Model,
-		},
+		Tools:    options.Options.Tools,
+		Config:   callbackConfig(options),
 	})
 
-	// 3. 创建流式响应
+	// 3. 建立流式连接；连接失败会按RetryCount重试，开始读取后不再重试
+	body, err := m.buildRequest(messages, options, true)
+	if err != nil {
+		ctx = callbacks.OnError(ctx, err)
+		return nil, err
+	}
+	resp, cancel, err := m.openStream(ctx, "/chat/completions", body, options)
+	if err != nil {
+		ctx = callbacks.OnError(ctx, err)
+		return nil, err
+	}
+
+	// 创建流式响应
 	// Pipe产生一个StreamReader和一个StreamWrite，向StreamWrite中写入可以从StreamReader中读到，二者并发安全。
 	// 实现中异步向StreamWrite中写入生成内容，返回StreamReader作为返回值
 	// ***StreamReader是一个数据流，仅可读一次，组件自行实现Callback时，既需要通过OnEndWithCallbackOutput向callback传递数据流，也需要向返回一个数据流，需要对数据流进行一次拷贝
 	// 考虑到此种情形总是需要拷贝数据流，OnEndWithCallbackOutput函数会在内部拷贝并返回一个未被读取的流
-	// 以下代码演示了一种流处理方式，处理方式不唯一
 	sr, sw := schema.Pipe[*model.CallbackOutput](1)
 
 	// 4. 启动异步生成
 	go func() {
 		defer sw.Close()
+		defer cancel()
+		defer resp.Body.Close()
 
 		// 流式写入
-		m.doStream(ctx, messages, options, sw)
+		m.doStream(resp, options, sw)
 	}()
 
 	// 5. 完成回调
@@ -122,20 +188,236 @@ func (m *HomaChatModel) Stream(ctx context.Context, messages []*schema.Message,
 	}), nil
 }
 
+// WithTools 返回绑定了工具的新实例，不修改当前实例
 func (m *HomaChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
-	// 实现工具绑定逻辑
-	return nil, nil
+	if len(tools) == 0 {
+		return nil, errors.New("no tools to bind")
+	}
+	bound := *m
+	bound.tools = tools
+	return &bound, nil
 }
 
-func (m *HomaChatModel) doGenerate(ctx context.Context, messages []*schema.Message, opts *MyChatModelOptions) (*schema.Message, error) {
-	// 实现生成逻辑
-	return &schema.Message{
-		Role: schema.Assistant,
-		Content: "Hello Wolrd!",
-	}, nil
+func (m *HomaChatModel) getOptions(opts ...model.Option) *MyChatModelOptions {
+	options := &MyChatModelOptions{
+		Options: &model.Options{
+			Model: &m.model,
+			Tools: m.tools,
+		},
+		RetryCount: m.retryCount,
+		Timeout:    m.timeout,
+	}
+	options.Options = model.GetCommonOptions(options.Options, opts...)
+	return model.GetImplSpecificOptions(options, opts...)
+}
+
+func callbackConfig(options *MyChatModelOptions) *model.Config {
+	config := &model.Config{Stop: options.Options.Stop}
+	if options.Options.Model != nil {
+		config.Model = *options.Options.Model
+	}
+	if options.Options.MaxTokens != nil {
+		config.MaxTokens = *options.Options.MaxTokens
+	}
+	if options.Options.Temperature != nil {
+		config.Temperature = *options.Options.Temperature
+	}
+	if options.Options.TopP != nil {
+		config.TopP = *options.Options.TopP
+	}
+	return config
+}
+
+func (m *HomaChatModel) buildRequest(messages []*schema.Message, opts *MyChatModelOptions, stream bool) ([]byte, error) {
+	o := opts.Options
+	req := chatRequest{
+		Messages:    toChatMessages(messages),
+		Temperature: o.Temperature,
+		MaxTokens:   o.MaxTokens,
+		TopP:        o.TopP,
+		Stop:        o.Stop,
+		ToolChoice:  toToolChoice(o.ToolChoice),
+		Stream:      stream,
+	}
+	if o.Model != nil {
+		req.Model = *o.Model
+	}
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if len(o.Tools) > 0 {
+		tools, err := toChatTools(o.Tools)
+		if err != nil {
+			return nil, err
+		}
+		req.Tools = tools
+	}
+	if stream {
+		req.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	return json.Marshal(req)
+}
+
+// do 向 {baseURL}{path} 发送一次请求；非2xx响应转换为 *APIError
+func (m *HomaChatModel) do(ctx context.Context, path string, body []byte, stream bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, readAPIError(resp)
+	}
+	return resp, nil
 }
 
-func (m *HomaChatModel) doStream(ctx context.Context, messages []*schema.Message, opts *MyChatModelOptions, sr *schema.StreamWriter[*model.CallbackOutput]) {
-	// 流式生成文本写入sr中
-	return
+// Ping 检查模型服务是否可达，请求 {baseURL}/models
+func (m *HomaChatModel) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+"/models", nil)
+	if err != nil {
+		return err
+	}
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return readAPIError(resp)
+	}
+	return nil
+}
+
+// retry 调用attempt直到成功、遇到不可重试的错误或用完重试次数
+func retry(ctx context.Context, retryCount int, attempt func() error) error {
+	delay := retryBaseDelay
+	for i := 0; ; i++ {
+		err := attempt()
+		if err == nil || i >= retryCount || !retryable(ctx, err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+// retryable 判断错误是否值得重试：限流、5xx、单次超时和网络错误
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	// 其余为传输层错误（连接失败、单次请求超时等）
+	return true
+}
+
+func (m *HomaChatModel) doGenerate(ctx context.Context, messages []*schema.Message, opts *MyChatModelOptions) (*schema.Message, *chatUsage, error) {
+	body, err := m.buildRequest(messages, opts, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result chatResponse
+	err = retry(ctx, opts.RetryCount, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+
+		resp, err := m.do(attemptCtx, "/chat/completions", body, false)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		result = chatResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("invalid chat completions response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.Error != nil {
+		return nil, nil, &APIError{StatusCode: http.StatusOK, Message: result.Error.Message, Type: result.Error.Type}
+	}
+	if len(result.Choices) == 0 {
+		return nil, nil, errors.New("chat completions response has no choices")
+	}
+
+	choice := result.Choices[0]
+	return fromChatMessage(choice.Message, choice.FinishReason, result.Usage), result.Usage, nil
+}
+
+// openStream 建立流式连接。超时只作用于收到响应头之前，返回的cancel在读完流后调用
+func (m *HomaChatModel) openStream(ctx context.Context, path string, body []byte, opts *MyChatModelOptions) (*http.Response, context.CancelFunc, error) {
+	var resp *http.Response
+	var cancel context.CancelFunc
+	err := retry(ctx, opts.RetryCount, func() error {
+		streamCtx, streamCancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(opts.Timeout, streamCancel)
+		r, err := m.do(streamCtx, path, body, true)
+		if !timer.Stop() && err != nil {
+			err = fmt.Errorf("no response within %s: %w", opts.Timeout, err)
+		}
+		if err != nil {
+			streamCancel()
+			return err
+		}
+		resp, cancel = r, streamCancel
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, cancel, nil
+}
+
+func (m *HomaChatModel) doStream(resp *http.Response, opts *MyChatModelOptions, sw *schema.StreamWriter[*model.CallbackOutput]) {
+	// 流式生成文本写入sw中
+	config := callbackConfig(opts)
+	err := readEvents(resp.Body, func(chunk *chatResponse) error {
+		out := &model.CallbackOutput{Config: config, TokenUsage: toCallbackUsage(chunk.Usage)}
+		if len(chunk.Choices) > 0 {
+			choice := chunk.Choices[0]
+			out.Message = fromChatMessage(choice.Delta, choice.FinishReason, chunk.Usage)
+		} else if chunk.Usage != nil {
+			// include_usage 时最后一个事件只有用量
+			out.Message = fromChatMessage(nil, "", chunk.Usage)
+		} else {
+			return nil
+		}
+		if closed := sw.Send(out, nil); closed {
+			return errStreamClosed
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStreamClosed) {
+		sw.Send(nil, err)
+	}
 }
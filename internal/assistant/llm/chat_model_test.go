@@ -0,0 +1,252 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// newTestModel 返回请求srv的模型
+func newTestModel(t *testing.T, srv *httptest.Server, config HomaChatModelConfig) *HomaChatModel {
+	t.Helper()
+	config.BaseURL = srv.URL
+	config.Model = "test-model"
+	m, err := NewHomaChatModel(&config)
+	if err != nil {
+		t.Fatalf("NewHomaChatModel: %v", err)
+	}
+	return m
+}
+
+// sse 把事件写成SSE流
+func sse(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		fmt.Fprintf(w, "data: %s\n\n", event)
+	}
+}
+
+func userMessage(content string) []*schema.Message {
+	return []*schema.Message{schema.UserMessage(content)}
+}
+
+// readStream 读完流并拼接所有消息
+func readStream(t *testing.T, sr *schema.StreamReader[*schema.Message]) ([]*schema.Message, error) {
+	t.Helper()
+	defer sr.Close()
+	var msgs []*schema.Message
+	for {
+		msg, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			return msgs, nil
+		}
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestStreamParsesEventsAndUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("Accept = %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		sse(w,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"think"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":", world"},"finish_reason":"stop"}]}`,
+			// include_usage 时最后一个事件只有用量
+			`{"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3,"total_tokens":10,"prompt_tokens_details":{"cached_tokens":4}}}`,
+			`[DONE]`,
+		)
+	}))
+	defer srv.Close()
+
+	sr, err := newTestModel(t, srv, HomaChatModelConfig{}).Stream(context.Background(), userMessage("hi"))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	msgs, err := readStream(t, sr)
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if len(msgs) != 4 {
+		t.Fatalf("got %d messages, want 4", len(msgs))
+	}
+
+	msg, err := schema.ConcatMessages(msgs)
+	if err != nil {
+		t.Fatalf("ConcatMessages: %v", err)
+	}
+	if msg.Content != "Hello, world" || msg.ReasoningContent != "think" {
+		t.Errorf("content = %q, reasoning = %q", msg.Content, msg.ReasoningContent)
+	}
+	if msg.ResponseMeta == nil || msg.ResponseMeta.FinishReason != "stop" {
+		t.Errorf("finish reason missing: %+v", msg.ResponseMeta)
+	}
+	usage := msgs[3].ResponseMeta.Usage
+	if usage == nil || usage.PromptTokens != 7 || usage.CompletionTokens != 3 || usage.TotalTokens != 10 || usage.PromptTokenDetails.CachedTokens != 4 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestStreamToolCallDeltas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sse(w,
+			`{"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"query_weather","arguments":""}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"now","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`,
+			`[DONE]`,
+		)
+	}))
+	defer srv.Close()
+
+	sr, err := newTestModel(t, srv, HomaChatModelConfig{}).Stream(context.Background(), userMessage("weather?"))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	msgs, err := readStream(t, sr)
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	msg, err := schema.ConcatMessages(msgs)
+	if err != nil {
+		t.Fatalf("ConcatMessages: %v", err)
+	}
+
+	want := []schema.ToolCall{
+		{ID: "call_1", Function: schema.FunctionCall{Name: "query_weather", Arguments: `{"city":"Paris"}`}},
+		{ID: "call_2", Function: schema.FunctionCall{Name: "now", Arguments: `{}`}},
+	}
+	if len(msg.ToolCalls) != len(want) {
+		t.Fatalf("got %d tool calls, want %d: %+v", len(msg.ToolCalls), len(want), msg.ToolCalls)
+	}
+	for i, tc := range msg.ToolCalls {
+		if tc.ID != want[i].ID || tc.Function != want[i].Function {
+			t.Errorf("tool call %d = %+v, want %+v", i, tc, want[i])
+		}
+	}
+	if msg.ResponseMeta == nil || msg.ResponseMeta.FinishReason != "tool_calls" {
+		t.Errorf("finish reason missing: %+v", msg.ResponseMeta)
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 没有 [DONE] 就断开
+		sse(w, `{"choices":[{"index":0,"delta":{"content":"Hel"}}]}`)
+	}))
+	defer srv.Close()
+
+	sr, err := newTestModel(t, srv, HomaChatModelConfig{}).Stream(context.Background(), userMessage("hi"))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	msgs, err := readStream(t, sr)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if len(msgs) != 1 || msgs[0].Content != "Hel" {
+		t.Errorf("messages before the error = %+v", msgs)
+	}
+}
+
+func TestGenerateRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"rate limited", http.StatusTooManyRequests, 2, false},
+		{"server error", http.StatusBadGateway, 2, false},
+		{"client error", http.StatusBadRequest, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					http.Error(w, `{"error":{"message":"try again","type":"server_error"}}`, tt.status)
+					return
+				}
+				fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+			}))
+			defer srv.Close()
+
+			m := newTestModel(t, srv, HomaChatModelConfig{RetryCount: 1})
+			msg, err := m.Generate(context.Background(), userMessage("hi"))
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+			if tt.wantErr {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+					t.Fatalf("err = %v, want APIError with status %d", err, tt.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if msg.Content != "ok" {
+				t.Errorf("content = %q", msg.Content)
+			}
+		})
+	}
+}
+
+func TestStreamFirstResponseTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	m := newTestModel(t, srv, HomaChatModelConfig{Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := m.Stream(context.Background(), userMessage("hi"))
+	if err == nil || !strings.Contains(err.Error(), "no response within") {
+		t.Fatalf("err = %v, want a first-response timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timed out after %s", elapsed)
+	}
+}
+
+func TestStreamTimeoutOnlyBoundsFirstResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sse(w, `{"choices":[{"index":0,"delta":{"content":"slow"}}]}`)
+		w.(http.Flusher).Flush()
+		time.Sleep(150 * time.Millisecond)
+		sse(w, `{"choices":[{"index":0,"delta":{"content":" reply"}}]}`, `[DONE]`)
+	}))
+	defer srv.Close()
+
+	m := newTestModel(t, srv, HomaChatModelConfig{Timeout: 50 * time.Millisecond})
+	sr, err := m.Stream(context.Background(), userMessage("hi"))
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	msgs, err := readStream(t, sr)
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("got %d messages, want 2", len(msgs))
+	}
+}
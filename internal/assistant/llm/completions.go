@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// OpenAI兼容的legacy completions协议，用于原生支持FIM的代码模型：
+// prompt原样交给模型，不套chat模板
+
+type completionRequest struct {
+	Model         string         `json:"model"`
+	Prompt        string         `json:"prompt"`
+	Temperature   *float32       `json:"temperature,omitempty"`
+	MaxTokens     *int           `json:"max_tokens,omitempty"`
+	TopP          *float32       `json:"top_p,omitempty"`
+	Stop          []string       `json:"stop,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type completionResponse struct {
+	Choices []completionChoice `json:"choices"`
+	Usage   *chatUsage         `json:"usage,omitempty"`
+	Error   *apiError          `json:"error,omitempty"`
+}
+
+type completionChoice struct {
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// Complete 通过 {baseURL}/completions 续写prompt，返回生成的文本
+// 支持model.WithTemperature、WithMaxTokens、WithTopP、WithStop以及重试和超时选项
+func (m *HomaChatModel) Complete(ctx context.Context, prompt string, opts ...model.Option) (string, error) {
+	// 1. 处理选项
+	options := m.getOptions(opts...)
+	body, err := buildCompletionRequest(prompt, options, false)
+	if err != nil {
+		return "", err
+	}
+
+	// 2. 发送请求，失败时按重试策略重试
+	var result completionResponse
+	err = retry(ctx, options.RetryCount, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+
+		resp, err := m.do(attemptCtx, "/completions", body, false)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		result = completionResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("invalid completions response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// 3. 处理响应
+	if result.Error != nil {
+		return "", &APIError{StatusCode: http.StatusOK, Message: result.Error.Message, Type: result.Error.Type}
+	}
+	if len(result.Choices) == 0 {
+		return "", errors.New("completions response has no choices")
+	}
+	if options.Usage != nil && result.Usage != nil {
+		*options.Usage = *toSchemaUsage(result.Usage)
+	}
+	return result.Choices[0].Text, nil
+}
+
+// CompleteStream 与Complete相同，但在生成过程中逐段返回文本
+// 连接失败会按RetryCount重试，开始读取后不再重试
+func (m *HomaChatModel) CompleteStream(ctx context.Context, prompt string, opts ...model.Option) (*schema.StreamReader[string], error) {
+	// 1. 处理选项
+	options := m.getOptions(opts...)
+	body, err := buildCompletionRequest(prompt, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 建立流式连接
+	resp, cancel, err := m.openStream(ctx, "/completions", body, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 异步读取SSE事件，写入sw
+	sr, sw := schema.Pipe[string](1)
+	go func() {
+		defer sw.Close()
+		defer cancel()
+		defer resp.Body.Close()
+
+		err := readEvents(resp.Body, func(chunk *chatResponse) error {
+			// 用量在最后一个事件中，通常不带choices
+			if options.Usage != nil && chunk.Usage != nil {
+				*options.Usage = *toSchemaUsage(chunk.Usage)
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Text == "" {
+				return nil
+			}
+			if closed := sw.Send(chunk.Choices[0].Text, nil); closed {
+				return errStreamClosed
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStreamClosed) {
+			sw.Send("", err)
+		}
+	}()
+	return sr, nil
+}
+
+func buildCompletionRequest(prompt string, opts *MyChatModelOptions, stream bool) ([]byte, error) {
+	o := opts.Options
+	req := completionRequest{
+		Prompt:      prompt,
+		Temperature: o.Temperature,
+		MaxTokens:   o.MaxTokens,
+		TopP:        o.TopP,
+		Stop:        o.Stop,
+		Stream:      stream,
+	}
+	if stream {
+		req.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	if o.Model != nil {
+		req.Model = *o.Model
+	}
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	return json.Marshal(req)
+}
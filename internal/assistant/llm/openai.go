@@ -0,0 +1,293 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// OpenAI兼容的chat completions协议（vLLM、llama.cpp server、Ollama等均支持）
+
+type chatRequest struct {
+	Model         string         `json:"model"`
+	Messages      []chatMessage  `json:"messages"`
+	Temperature   *float32       `json:"temperature,omitempty"`
+	MaxTokens     *int           `json:"max_tokens,omitempty"`
+	TopP          *float32       `json:"top_p,omitempty"`
+	Stop          []string       `json:"stop,omitempty"`
+	Tools         []chatTool     `json:"tools,omitempty"`
+	ToolChoice    string         `json:"tool_choice,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatMessage struct {
+	Role       string         `json:"role,omitempty"`
+	Content    string         `json:"content"`
+	Name       string         `json:"name,omitempty"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	// 推理模型（DeepSeek、vLLM的reasoning parser等）返回的思考过程，只读不写
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+}
+
+type chatToolCall struct {
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function chatFunctionCall `json:"function"`
+}
+
+type chatFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type chatResponse struct {
+	Choices []chatChoice `json:"choices"`
+	Usage   *chatUsage   `json:"usage,omitempty"`
+	Error   *apiError    `json:"error,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int          `json:"index"`
+	Message      *chatMessage `json:"message,omitempty"`
+	Delta        *chatMessage `json:"delta,omitempty"`
+	Text         string       `json:"text,omitempty"` // completions协议（含流式）的生成文本
+	FinishReason string       `json:"finish_reason,omitempty"`
+}
+
+type chatUsage struct {
+	PromptTokens        int                `json:"prompt_tokens"`
+	CompletionTokens    int                `json:"completion_tokens"`
+	TotalTokens         int                `json:"total_tokens"`
+	PromptTokensDetails *promptTokenDetail `json:"prompt_tokens_details,omitempty"`
+}
+
+type promptTokenDetail struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// cachedTokens 返回命中上下文缓存的prompt token数，服务端未报告时为0
+func (u *chatUsage) cachedTokens() int {
+	if u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
+}
+
+type apiError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// APIError 是服务端返回的非2xx响应
+type APIError struct {
+	StatusCode int
+	Message    string
+	Type       string
+}
+
+func (e *APIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("chat completions failed with status %d (%s): %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("chat completions failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary 表示请求可以重试：限流或服务端错误
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// readAPIError 读取错误响应体，兼容 {"error": {...}} 以及纯文本
+func readAPIError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	e := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && len(payload.Error) > 0 {
+		var detail apiError
+		if json.Unmarshal(payload.Error, &detail) == nil && detail.Message != "" {
+			e.Message, e.Type = detail.Message, detail.Type
+		} else {
+			// Ollama等返回 {"error": "..."}
+			var msg string
+			if json.Unmarshal(payload.Error, &msg) == nil {
+				e.Message = msg
+			}
+		}
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+func toChatMessages(messages []*schema.Message) []chatMessage {
+	result := make([]chatMessage, 0, len(messages))
+	for _, m := range messages {
+		cm := chatMessage{
+			Role:       string(m.Role),
+			Content:    m.Content,
+			Name:       m.Name,
+			ToolCallID: m.ToolCallID,
+		}
+		for _, tc := range m.ToolCalls {
+			typ := tc.Type
+			if typ == "" {
+				typ = "function"
+			}
+			cm.ToolCalls = append(cm.ToolCalls, chatToolCall{
+				ID:       tc.ID,
+				Type:     typ,
+				Function: chatFunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+			})
+		}
+		result = append(result, cm)
+	}
+	return result
+}
+
+func toChatTools(tools []*schema.ToolInfo) ([]chatTool, error) {
+	result := make([]chatTool, 0, len(tools))
+	for _, t := range tools {
+		params := json.RawMessage(`{"type":"object","properties":{}}`)
+		s, err := t.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters for tool %s: %w", t.Name, err)
+		}
+		if s != nil {
+			if params, err = json.Marshal(s); err != nil {
+				return nil, fmt.Errorf("invalid parameters for tool %s: %w", t.Name, err)
+			}
+		}
+		result = append(result, chatTool{
+			Type:     "function",
+			Function: chatFunction{Name: t.Name, Description: t.Desc, Parameters: params},
+		})
+	}
+	return result, nil
+}
+
+func toToolChoice(choice *schema.ToolChoice) string {
+	if choice == nil {
+		return ""
+	}
+	switch *choice {
+	case schema.ToolChoiceForbidden:
+		return "none"
+	case schema.ToolChoiceForced:
+		return "required"
+	case schema.ToolChoiceAllowed:
+		return "auto"
+	}
+	return ""
+}
+
+func fromChatMessage(cm *chatMessage, finishReason string, usage *chatUsage) *schema.Message {
+	msg := &schema.Message{Role: schema.Assistant}
+	if cm != nil {
+		msg.Content = cm.Content
+		msg.ReasoningContent = cm.ReasoningContent
+		if cm.Role != "" {
+			msg.Role = schema.RoleType(cm.Role)
+		}
+		for _, tc := range cm.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+				Index:    tc.Index,
+				ID:       tc.ID,
+				Type:     tc.Type,
+				Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+			})
+		}
+	}
+	if finishReason != "" || usage != nil {
+		msg.ResponseMeta = &schema.ResponseMeta{FinishReason: finishReason, Usage: toSchemaUsage(usage)}
+	}
+	return msg
+}
+
+func toSchemaUsage(usage *chatUsage) *schema.TokenUsage {
+	if usage == nil {
+		return nil
+	}
+	return &schema.TokenUsage{
+		PromptTokens:       usage.PromptTokens,
+		PromptTokenDetails: schema.PromptTokenDetails{CachedTokens: usage.cachedTokens()},
+		CompletionTokens:   usage.CompletionTokens,
+		TotalTokens:        usage.TotalTokens,
+	}
+}
+
+func toCallbackUsage(usage *chatUsage) *model.TokenUsage {
+	if usage == nil {
+		return nil
+	}
+	return &model.TokenUsage{
+		PromptTokens:       usage.PromptTokens,
+		PromptTokenDetails: model.PromptTokenDetails{CachedTokens: usage.cachedTokens()},
+		CompletionTokens:   usage.CompletionTokens,
+		TotalTokens:        usage.TotalTokens,
+	}
+}
+
+// readEvents 逐条解析SSE的data行，直到 [DONE]
+// 连接在 [DONE] 之前结束说明回复被截断，返回 io.ErrUnexpectedEOF
+func readEvents(body io.Reader, handle func(*chatResponse) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), 4<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// 空行、注释(:)以及event/id字段
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid stream event: %w", err)
+		}
+		if chunk.Error != nil {
+			return &APIError{StatusCode: http.StatusOK, Message: chunk.Error.Message, Type: chunk.Error.Type}
+		}
+		if err := handle(&chunk); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream ended before [DONE]: %w", io.ErrUnexpectedEOF)
+}
+
+// errStreamClosed 表示调用方已关闭StreamReader
+var errStreamClosed = errors.New("stream closed by reader")
@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		wantType    string
+		temporary   bool
+	}{
+		{
+			name:        "openai error object",
+			status:      http.StatusTooManyRequests,
+			body:        `{"error":{"message":"Rate limit reached","type":"rate_limit_error"}}`,
+			wantMessage: "Rate limit reached",
+			wantType:    "rate_limit_error",
+			temporary:   true,
+		},
+		{
+			name:        "ollama error string",
+			status:      http.StatusNotFound,
+			body:        `{"error":"model \"llama3\" not found"}`,
+			wantMessage: `model "llama3" not found`,
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusBadGateway,
+			body:        "upstream connect error\n",
+			wantMessage: "upstream connect error",
+			temporary:   true,
+		},
+		{
+			name:        "empty body",
+			status:      http.StatusServiceUnavailable,
+			wantMessage: "Service Unavailable",
+			temporary:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(tt.status)
+			rec.WriteString(tt.body)
+
+			err := readAPIError(rec.Result())
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage || apiErr.Type != tt.wantType {
+				t.Errorf("got %+v, want status %d, message %q, type %q", apiErr, tt.status, tt.wantMessage, tt.wantType)
+			}
+			if apiErr.Temporary() != tt.temporary {
+				t.Errorf("Temporary() = %v, want %v", apiErr.Temporary(), tt.temporary)
+			}
+		})
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		stream     string
+		wantChunks int
+		wantErr    error
+		wantAPIErr bool
+	}{
+		{
+			name:       "done",
+			stream:     "data: {\"choices\":[]}\n\nevent: ping\n: comment\ndata:{\"choices\":[]}\n\ndata: [DONE]\n\n",
+			wantChunks: 2,
+		},
+		{
+			name:       "ignores events after done",
+			stream:     "data: [DONE]\n\ndata: not json\n\n",
+			wantChunks: 0,
+		},
+		{
+			name:       "truncated",
+			stream:     "data: {\"choices\":[]}\n\n",
+			wantChunks: 1,
+			wantErr:    io.ErrUnexpectedEOF,
+		},
+		{
+			name:       "error event",
+			stream:     "data: {\"error\":{\"message\":\"overloaded\",\"type\":\"server_error\"}}\n\n",
+			wantAPIErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := 0
+			err := readEvents(strings.NewReader(tt.stream), func(*chatResponse) error {
+				chunks++
+				return nil
+			})
+			if chunks != tt.wantChunks {
+				t.Errorf("handled %d chunks, want %d", chunks, tt.wantChunks)
+			}
+			var apiErr *APIError
+			switch {
+			case tt.wantAPIErr:
+				if !errors.As(err, &apiErr) || apiErr.Message != "overloaded" {
+					t.Errorf("err = %v, want APIError", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 
 	"github.com/cloudwego/eino-ext/components/model/gemini"
 	"github.com/cloudwego/eino/callbacks"
@@ -19,91 +18,46 @@ import (
 	"github.com/cloudwego/eino/flow/agent"
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
-	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/googleai"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/rpcplugin"
 	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
-	"golang.org/x/net/proxy"
-	"google.golang.org/genai"
 )
 
 var (
-	httpClient   *http.Client
-	geminiApiKey string
-
-	codeCompletionPrompt string
+	conn            googleai.Conn
+	chatModelName   string
+	completionModel string
 )
 
-func init() {
-	geminiApiKey = cfg.GetAppConfig().GetString("services.gemini.api-key")
-	if geminiApiKey == "" {
-		geminiApiKey = os.Getenv("GOOGLE_API_KEY")
-		log.Fatal("GOOGLE_API_KEY environment variable not set")
-	}
-
-	proxyUrl := cfg.GetAppConfig().GetString("app.proxy-url")
-	if proxyUrl == "" {
-		proxyUrl = os.Getenv("https_proxy")
-	}
-
-	if len(proxyUrl) > 0 {
-		// SOCKS proxy address
-		proxyURL, err := url.Parse(proxyUrl)
-		if err != nil {
-			panic(err)
-		}
-
-		// Create a SOCKS5 dialer
-		dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
-		if err != nil {
-			panic(err)
-		}
-
-		// Create HTTP client with the SOCKS5 dialer
-		httpTransport := &http.Transport{
-			Dial: dialer.Dial,
-		}
-		httpClient = &http.Client{Transport: httpTransport}
-	} else {
-		httpClient = &http.Client{}
-	}
-
-	// 1. Define the template string
-	codeCompletionPrompt = `
-	You are a highly skilled and efficient code completion assistant. Your task is to generate the most logical and correct completion for the code snippet provided below.
-
-The user is working in a file named: {{.filename}} in workspace {{.workspace}}
-
-The full code content of the file is:
----
-{{.code_before_cursor}}
-{{.cursor_here}}{{.code_after_cursor}}
----
-
-Your response should be a clean, single block of code that logically follows the cursor position. Do not include any extra text, explanations, or conversational filler. Just the code.
-	`
-
-}
-
 type QueryWeatherParams struct {
 	City *string `json:"city,omitempty" jsonschema:"description=City"`
 }
 
 // 处理函数
-func QueryWeatherFunc(_ context.Context, params *QueryWeatherParams) (string, error) {
+func QueryWeatherFunc(ctx context.Context, params *QueryWeatherParams) (string, error) {
 	log.Println("querying weather")
 	reqUrl := "https://wttr.in/?T"
-	if len(*params.City) > 0 {
+	if params.City != nil && len(*params.City) > 0 {
 		reqUrl = "https://wttr.in/" + url.PathEscape(*params.City) + "?T"
 		log.Println("request url", reqUrl)
 	}
-	resp, err := http.DefaultClient.Get(reqUrl)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqUrl, nil)
 	if err != nil {
-		panic(err)
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("query weather: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("query weather: unexpected status %s", resp.Status)
+	}
+
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("query weather: %w", err)
 	}
 
 	reply := string(data)
@@ -185,48 +139,73 @@ func (cb *LoggerCallback) OnStartWithStreamInput(ctx context.Context, info *call
 // EinoCopilotPlugin is a mock implementation of the CopilotPlugin interface
 type EinoCopilotPlugin struct{}
 
-// Chat simulates streaming data chunks to the client
-func (p EinoCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData, error) {
-	ch := make(chan shared.ChunkData)
+// Init validates the Gemini configuration and creates the shared client. The
+// host refuses the plugin if it returns an error.
+func (p EinoCopilotPlugin) Init(config shared.PluginConfig) error {
+	conf, err := googleai.LoadConfig(config)
+	if err != nil {
+		return err
+	}
+	c, err := conf.NewClient(context.Background())
+	if err != nil {
+		return err
+	}
 
-	go func() {
-		defer close(ch) // Ensure the channel is closed when done
+	conn.Set(c, conf.HTTPClient)
+	chatModelName = config.GetString("chat-model")
+	if chatModelName == "" {
+		chatModelName = "gemini-2.5-flash"
+	}
+	completionModel = config.GetString("completion-model")
+	if completionModel == "" {
+		completionModel = "gemini-2.0-flash"
+	}
+	return nil
+}
 
-		ctx := context.Background()
+// Health checks that the chat model is reachable with our API key.
+func (p EinoCopilotPlugin) Health(ctx context.Context) error {
+	client, release, err := conn.Acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return googleai.Health(ctx, client, chatModelName)
+}
 
-		// SOCKS proxy address
-		proxyURL, err := url.Parse("socks5://127.0.0.1:1080")
-		if err != nil {
-			panic(err)
-		}
+// Close waits for running calls, then drops the client and its idle
+// connections.
+func (p EinoCopilotPlugin) Close() error {
+	conn.Close()
+	return nil
+}
 
-		// Create a SOCKS5 dialer
-		dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
-		if err != nil {
-			panic(err)
-		}
+// Chat simulates streaming data chunks to the client
+func (p EinoCopilotPlugin) Chat(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	client, release, err := conn.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan shared.ChunkData)
 
-		// Create HTTP client with the SOCKS5 dialer
-		httpTransport := &http.Transport{
-			Dial: dialer.Dial,
-		}
-		httpClient := &http.Client{Transport: httpTransport}
+	go func() {
+		defer release()
+		defer close(ch) // Ensure the channel is closed when done
+		defer shared.Recover(ctx, ch)
 
-		client, err := genai.NewClient(ctx, &genai.ClientConfig{
-			APIKey:     geminiApiKey,
-			Backend:    genai.BackendGeminiAPI,
-			HTTPClient: httpClient,
-		})
-		if err != nil {
-			log.Fatal(err)
+		// fail reports err as the final chunk of the stream
+		fail := func(err error) {
+			log.Printf("chat failed: %v", err)
+			shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: googleai.ClassifyError(err)})
 		}
 
-		chatModel, err := gemini.NewChatModel(context.Background(), &gemini.Config{
+		chatModel, err := gemini.NewChatModel(ctx, &gemini.Config{
 			Client: client,
-			Model:  "gemini-2.5-flash",
+			Model:  chatModelName,
 		})
 		if err != nil {
-			panic(err)
+			fail(err)
+			return
 		}
 
 		// prepare persona (system prompt) (optional)
@@ -238,18 +217,23 @@ func (p EinoCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData
 			"A tool to query weather, will use default location if no city provide", // tool description
 			QueryWeatherFunc)
 		if err != nil {
-			panic(err)
+			fail(err)
+			return
 		}
 
+		report := func(event shared.ToolEvent) {
+			shared.Send(ctx, ch, shared.ChunkData{Tool: &event})
+		}
 		ragent, err := react.NewAgent(ctx, &react.AgentConfig{
 			ToolCallingModel: chatModel,
 			ToolsConfig: compose.ToolsNodeConfig{
-				Tools: []tool.BaseTool{updateTool},
+				Tools: []tool.BaseTool{confirmedTool{InvokableTool: updateTool, report: report}},
 			},
 			// StreamToolCallChecker: toolCallChecker, // uncomment it to replace the default tool call checker with custom one
 		})
 		if err != nil {
-			panic(err)
+			fail(err)
+			return
 		}
 
 		opt := []agent.AgentOption{
@@ -268,7 +252,7 @@ func (p EinoCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData
 			},
 		}, opt...)
 		if err != nil {
-			log.Printf("failed to stream: %v", err)
+			fail(err)
 			return
 		}
 
@@ -284,13 +268,14 @@ func (p EinoCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData
 					break
 				}
 				// error
-				log.Printf("failed to recv: %v", err)
+				fail(err)
 				return
 			}
 
 			// 打字机打印
-			ch <- shared.ChunkData{
-				Content: msg.Content,
+			if !shared.Send(ctx, ch, messageChunk(msg)) {
+				log.Printf("chat cancelled: %v", ctx.Err())
+				return
 			}
 		}
 
@@ -300,40 +285,100 @@ func (p EinoCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData
 	return ch, nil
 }
 
-// AutoComplete simulates generating a single response
-func (p EinoCopilotPlugin) AutoComplete(req shared.UserRequest) (string, error) {
-	ctx := context.Background()
+// messageChunk converts a streamed agent message, including the model's
+// reasoning and finish reason. The model attaches its running usage to every
+// message, so usage is only reported with the finish reason, once it covers
+// the reply.
+func messageChunk(msg *schema.Message) shared.ChunkData {
+	chunk := shared.ChunkData{Content: msg.Content, Reasoning: msg.ReasoningContent}
+	if meta := msg.ResponseMeta; meta != nil {
+		chunk.FinishReason = shared.ParseFinishReason(meta.FinishReason)
+		if u := meta.Usage; u != nil && chunk.FinishReason != "" {
+			chunk.Usage = &shared.Usage{
+				PromptTokens:     u.PromptTokens,
+				CompletionTokens: u.CompletionTokens,
+				TotalTokens:      u.TotalTokens,
+				CachedTokens:     u.PromptTokenDetails.CachedTokens,
+			}
+		}
+	}
+	return chunk
+}
+
+// confirmedTool asks for approval before running a tool and reports the call
+// in the chat stream. A denied call tells the model so, which lets it answer
+// without the tool.
+type confirmedTool struct {
+	tool.InvokableTool
+	report func(shared.ToolEvent)
+}
 
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:     geminiApiKey,
-		Backend:    genai.BackendGeminiAPI,
-		HTTPClient: httpClient,
-	})
+func (t confirmedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	info, err := t.Info(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
+	}
+	call := shared.ToolCall{ID: compose.GetToolCallID(ctx), Name: info.Name, Arguments: argumentsInJSON}
+	approved, err := shared.ApproveTool(ctx, call)
+	if err != nil {
+		return "", err
+	}
+	if !approved {
+		t.report(shared.ToolEvent{Call: call, Done: true, Denied: true})
+		return "The user declined to run this tool.", nil
 	}
 
-	data, err := json.Marshal(req)
+	t.report(shared.ToolEvent{Call: call})
+	result, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
+	t.report(shared.ToolEvent{Call: call, Result: result, Done: true})
+	return result, nil
+}
 
-	result, err := client.Models.GenerateContent(
-		ctx,
-		"gemini-2.0-flash",
-		genai.Text(string(data)),
-		&genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{
-				Role:  genai.RoleModel,
-				Parts: []*genai.Part{{Text: codeCompletionPrompt}},
-			},
-		},
-	)
+// AutoComplete fills in the code between FrontPart and BackPart.
+func (p EinoCopilotPlugin) AutoComplete(ctx context.Context, req shared.UserRequest) (string, error) {
+	client, release, err := conn.Acquire()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return googleai.Complete(ctx, client, completionModel, req)
+}
+
+// AutoCompleteCandidates offers up to req.MaxCandidates completions.
+func (p EinoCopilotPlugin) AutoCompleteCandidates(ctx context.Context, req shared.UserRequest) ([]shared.Candidate, error) {
+	client, release, err := conn.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return googleai.CompleteCandidates(ctx, client, completionModel, req)
+}
+
+// AutoCompleteStream streams the completion as it is generated.
+func (p EinoCopilotPlugin) AutoCompleteStream(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	client, release, err := conn.Acquire()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return result.Text(), nil
+	ch, err := googleai.CompleteStream(ctx, client, completionModel, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return googleai.Hold(ctx, ch, release), nil
 }
 
 // Export the mock plugin instance
 var Plugin EinoCopilotPlugin
+
+var _ shared.PluginLifecycle = Plugin
+var _ shared.CandidateCompleter = Plugin
+var _ shared.StreamingCompleter = Plugin
+
+// main serves the plugin as a standalone process for the process mode
+func main() {
+	rpcplugin.Serve(Plugin)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+func TestMessageChunkCountsUsageOnce(t *testing.T) {
+	// The model reports its running usage with every message of a stream
+	usage := func(completion int) *schema.ResponseMeta {
+		return &schema.ResponseMeta{Usage: &schema.TokenUsage{
+			PromptTokens:       20,
+			PromptTokenDetails: schema.PromptTokenDetails{CachedTokens: 5},
+			CompletionTokens:   completion,
+			TotalTokens:        20 + completion,
+		}}
+	}
+	final := usage(9)
+	final.FinishReason = "STOP"
+	stream := []*schema.Message{
+		{Role: schema.Assistant, Content: "Hel", ResponseMeta: usage(3)},
+		{Role: schema.Assistant, Content: "lo, ", ResponseMeta: usage(6)},
+		{Role: schema.Assistant, Content: "world", ResponseMeta: final},
+	}
+
+	var total shared.Usage
+	var content string
+	for _, msg := range stream {
+		chunk := messageChunk(msg)
+		content += chunk.Content
+		if chunk.Usage != nil {
+			total.Add(*chunk.Usage)
+		}
+	}
+	if content != "Hello, world" {
+		t.Errorf("content = %q", content)
+	}
+	want := shared.Usage{PromptTokens: 20, CompletionTokens: 9, TotalTokens: 29, CachedTokens: 5}
+	if total != want {
+		t.Errorf("usage = %+v, want %+v", total, want)
+	}
+}
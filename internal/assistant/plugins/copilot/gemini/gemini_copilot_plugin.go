@@ -4,95 +4,77 @@ import (
 	"context"
 	"encoding/json"
 	"log"
-	"net/http"
-	"net/url"
-	"os"
 
-	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/googleai"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/rpcplugin"
 	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
-	"golang.org/x/net/proxy"
 	"google.golang.org/genai"
 )
 
 var (
-	httpClient   *http.Client
-	geminiApiKey string
-
-	codeCompletionPrompt string
+	conn            googleai.Conn
+	chatModel       string
+	completionModel string
+	showThoughts    bool
 )
 
-func init() {
-	geminiApiKey = cfg.GetAppConfig().GetString("services.gemini.api-key")
-	if geminiApiKey == "" {
-		geminiApiKey = os.Getenv("GOOGLE_API_KEY")
-		log.Fatal("GOOGLE_API_KEY environment variable not set")
-	}
+// GeminiCopilotPlugin is a mock implementation of the CopilotPlugin interface
+type GeminiCopilotPlugin struct{}
 
-	proxyUrl := cfg.GetAppConfig().GetString("app.proxy-url")
-	if proxyUrl == "" {
-		proxyUrl = os.Getenv("https_proxy")
+// Init validates the Gemini configuration and creates the shared client. The
+// host refuses the plugin if it returns an error.
+func (p GeminiCopilotPlugin) Init(config shared.PluginConfig) error {
+	conf, err := googleai.LoadConfig(config)
+	if err != nil {
+		return err
 	}
-
-	if len(proxyUrl) > 0 {
-		// SOCKS proxy address
-		proxyURL, err := url.Parse(proxyUrl)
-		if err != nil {
-			panic(err)
-		}
-
-		// Create a SOCKS5 dialer
-		dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
-		if err != nil {
-			panic(err)
-		}
-
-		// Create HTTP client with the SOCKS5 dialer
-		httpTransport := &http.Transport{
-			Dial: dialer.Dial,
-		}
-		httpClient = &http.Client{Transport: httpTransport}
-	} else {
-		httpClient = &http.Client{}
+	c, err := conf.NewClient(context.Background())
+	if err != nil {
+		return err
 	}
 
-	// 1. Define the template string
-	codeCompletionPrompt = `
-	You are a highly skilled and efficient code completion assistant. Your task is to generate the most logical and correct completion for the code snippet provided below.
-
-The user is working in a file named: {{.filename}} in workspace {{.workspace}}
-
-The full code content of the file is:
----
-{{.code_before_cursor}}
-{{.cursor_here}}{{.code_after_cursor}}
----
-
-Your response should be a clean, single block of code that logically follows the cursor position. Do not include any extra text, explanations, or conversational filler. Just the code.
-	`
-
-	
+	conn.Set(c, conf.HTTPClient)
+	chatModel = config.GetString("chat-model")
+	if chatModel == "" {
+		chatModel = "gemini-2.5-flash"
+	}
+	completionModel = config.GetString("completion-model")
+	if completionModel == "" {
+		completionModel = "gemini-2.0-flash"
+	}
+	showThoughts = config.GetBool("show-thoughts", false)
+	return nil
+}
 
+// Health checks that the completion model is reachable with our API key.
+func (p GeminiCopilotPlugin) Health(ctx context.Context) error {
+	client, release, err := conn.Acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return googleai.Health(ctx, client, completionModel)
 }
 
-// GeminiCopilotPlugin is a mock implementation of the CopilotPlugin interface
-type GeminiCopilotPlugin struct{}
+// Close waits for running calls, then drops the client and its idle
+// connections.
+func (p GeminiCopilotPlugin) Close() error {
+	conn.Close()
+	return nil
+}
 
 // Chat simulates streaming data chunks to the client
-func (p GeminiCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData, error) {
+func (p GeminiCopilotPlugin) Chat(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	client, release, err := conn.Acquire()
+	if err != nil {
+		return nil, err
+	}
 	ch := make(chan shared.ChunkData)
-	ctx := context.Background()
 
 	go func() {
+		defer release()
 		defer close(ch) // Ensure the channel is closed when done
-
-		client, err := genai.NewClient(ctx, &genai.ClientConfig{
-			APIKey:     geminiApiKey,
-			Backend:    genai.BackendGeminiAPI,
-			HTTPClient: httpClient,
-		})
-		if err != nil {
-			log.Fatal(err)
-		}
+		defer shared.Recover(ctx, ch)
 
 		// Marshal full request (including History) so model receives session context
 		data, err := json.Marshal(req)
@@ -102,17 +84,32 @@ func (p GeminiCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkDa
 			data = []byte(req.Message)
 		}
 
+		var config *genai.GenerateContentConfig
+		if showThoughts {
+			config = &genai.GenerateContentConfig{ThinkingConfig: &genai.ThinkingConfig{IncludeThoughts: true}}
+		}
 		stream := client.Models.GenerateContentStream(
 			ctx,
-			"gemini-2.5-flash",
+			chatModel,
 			genai.Text(string(data)),
-			nil,
+			config,
 		)
 
-		for chunk := range stream {
-			part := chunk.Candidates[0].Content.Parts[0]
-			ch <- shared.ChunkData{
-				Content: part.Text,
+		var chunker googleai.Chunker
+		for resp, err := range stream {
+			if err != nil {
+				log.Printf("failed to stream: %v", err)
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: googleai.ClassifyError(err)})
+				return
+			}
+			if blocked := googleai.BlockedError(resp); blocked != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: blocked})
+				return
+			}
+
+			// Returning stops the iterator, which cancels the upstream stream
+			if !shared.Send(ctx, ch, chunker.Chunk(resp)) {
+				return
 			}
 		}
 	}()
@@ -120,40 +117,48 @@ func (p GeminiCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkDa
 	return ch, nil
 }
 
-// AutoComplete simulates generating a single response
-func (p GeminiCopilotPlugin) AutoComplete(req shared.UserRequest) (string, error) {
-	ctx := context.Background()
-
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:     geminiApiKey,
-		Backend:    genai.BackendGeminiAPI,
-		HTTPClient: httpClient,
-	})
+// AutoComplete fills in the code between FrontPart and BackPart.
+func (p GeminiCopilotPlugin) AutoComplete(ctx context.Context, req shared.UserRequest) (string, error) {
+	client, release, err := conn.Acquire()
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
+	defer release()
+	return googleai.Complete(ctx, client, completionModel, req)
+}
 
-	data, err := json.Marshal(req)
+// AutoCompleteCandidates offers up to req.MaxCandidates completions.
+func (p GeminiCopilotPlugin) AutoCompleteCandidates(ctx context.Context, req shared.UserRequest) ([]shared.Candidate, error) {
+	client, release, err := conn.Acquire()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	defer release()
+	return googleai.CompleteCandidates(ctx, client, completionModel, req)
+}
 
-	result, err := client.Models.GenerateContent(
-		ctx,
-		"gemini-2.0-flash",
-		genai.Text(string(data)),
-		&genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{
-					Role:  genai.RoleModel,
-					Parts: []*genai.Part{{Text: codeCompletionPrompt}},
-			},
-		},
-	)
+// AutoCompleteStream streams the completion as it is generated.
+func (p GeminiCopilotPlugin) AutoCompleteStream(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	client, release, err := conn.Acquire()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return result.Text(), nil
+	ch, err := googleai.CompleteStream(ctx, client, completionModel, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return googleai.Hold(ctx, ch, release), nil
 }
 
 // Export the mock plugin instance
 var Plugin GeminiCopilotPlugin
+
+var _ shared.PluginLifecycle = Plugin
+var _ shared.CandidateCompleter = Plugin
+var _ shared.StreamingCompleter = Plugin
+
+// main serves the plugin as a standalone process for the process mode
+func main() {
+	rpcplugin.Serve(Plugin)
+}
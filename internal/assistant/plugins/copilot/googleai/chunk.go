@@ -0,0 +1,68 @@
+package googleai
+
+import (
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/genai"
+)
+
+// Chunker converts the responses of a Gemini stream into chunks. Gemini may
+// repeat citations in later responses; each source is reported once.
+type Chunker struct {
+	cited map[string]bool
+}
+
+// Chunk converts one streamed response, splitting thoughts from the reply.
+// Usage is only reported with the finish reason, once it covers the reply.
+func (c *Chunker) Chunk(resp *genai.GenerateContentResponse) shared.ChunkData {
+	var chunk shared.ChunkData
+	if len(resp.Candidates) == 0 {
+		return chunk
+	}
+	candidate := resp.Candidates[0]
+	if candidate.Content != nil {
+		for _, part := range candidate.Content.Parts {
+			if part.Thought {
+				chunk.Reasoning += part.Text
+			} else {
+				chunk.Content += part.Text
+			}
+		}
+	}
+
+	if meta := candidate.CitationMetadata; meta != nil {
+		for _, citation := range meta.Citations {
+			if citation == nil || c.cited[citation.URI] {
+				continue
+			}
+			if c.cited == nil {
+				c.cited = make(map[string]bool)
+			}
+			c.cited[citation.URI] = true
+			chunk.Citations = append(chunk.Citations, shared.Citation{
+				URI:        citation.URI,
+				Title:      citation.Title,
+				StartIndex: int(citation.StartIndex),
+				EndIndex:   int(citation.EndIndex),
+			})
+		}
+	}
+
+	if candidate.FinishReason != "" {
+		chunk.FinishReason = shared.ParseFinishReason(string(candidate.FinishReason))
+		chunk.Usage = Usage(resp.UsageMetadata)
+	}
+	return chunk
+}
+
+// Usage converts Gemini's usage metadata; thinking is billed as output.
+func Usage(u *genai.GenerateContentResponseUsageMetadata) *shared.Usage {
+	if u == nil {
+		return nil
+	}
+	return &shared.Usage{
+		PromptTokens:     int(u.PromptTokenCount),
+		CompletionTokens: int(u.CandidatesTokenCount + u.ThoughtsTokenCount),
+		TotalTokens:      int(u.TotalTokenCount),
+		CachedTokens:     int(u.CachedContentTokenCount),
+	}
+}
@@ -0,0 +1,140 @@
+package googleai
+
+import (
+	"context"
+	"math"
+
+	"github.com/qtopie/homa/internal/assistant/completion"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/genai"
+)
+
+const (
+	// maxCompletionTokens bounds a completion; the post-processing cuts it at
+	// the end of the block anyway.
+	maxCompletionTokens = 256
+
+	// maxCandidates is the most candidates Gemini generates in one call.
+	maxCandidates = 8
+)
+
+// Complete fills in the code at the cursor of req with a Gemini model.
+func Complete(ctx context.Context, client *genai.Client, model string, req shared.UserRequest) (string, error) {
+	req.MaxCandidates = 1
+	candidates, err := CompleteCandidates(ctx, client, model, req)
+	if err != nil || len(candidates) == 0 {
+		return "", err
+	}
+	return candidates[0].Content, nil
+}
+
+// CompleteCandidates asks a Gemini model for up to req.MaxCandidates
+// completions at the cursor. Gemini has no FIM tokens, so the file is sent as
+// a chat prompt with the cursor marked. Candidates are scored by their mean
+// token probability when the API reports it.
+func CompleteCandidates(ctx context.Context, client *genai.Client, model string, req shared.UserRequest) ([]shared.Candidate, error) {
+	in := completion.NewInput(req)
+	if in.Empty() {
+		return nil, shared.Errorf(shared.ErrInvalidRequest, "nothing to complete")
+	}
+	count := min(max(req.MaxCandidates, 1), maxCandidates)
+
+	system, user := completion.ChatPrompt(in)
+	config := completionConfig(system)
+	if count > 1 {
+		// A little more randomness so the candidates differ
+		config.Temperature = genai.Ptr[float32](0.6)
+		config.CandidateCount = int32(count)
+	}
+	result, err := client.Models.GenerateContent(ctx, model, genai.Text(user), config)
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	if usage := Usage(result.UsageMetadata); usage != nil {
+		shared.ReportUsage(ctx, *usage)
+	}
+	if blocked := BlockedError(result); blocked != nil {
+		return nil, blocked
+	}
+
+	var candidates []shared.Candidate
+	for _, c := range result.Candidates {
+		if c.Content == nil {
+			continue
+		}
+		var text string
+		for _, part := range c.Content.Parts {
+			if !part.Thought {
+				text += part.Text
+			}
+		}
+		candidate := shared.Candidate{Content: completion.PostProcess(in, text)}
+		if c.AvgLogprobs != 0 {
+			candidate.Score = float32(math.Exp(c.AvgLogprobs))
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// CompleteStream streams the completion at the cursor of req as Gemini
+// generates it. Generation stops once the completion reaches the end of its
+// line or block.
+func CompleteStream(ctx context.Context, client *genai.Client, model string, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	in := completion.NewInput(req)
+	if in.Empty() {
+		return nil, shared.Errorf(shared.ErrInvalidRequest, "nothing to complete")
+	}
+
+	system, user := completion.ChatPrompt(in)
+	config := completionConfig(system)
+
+	ch := make(chan shared.ChunkData)
+	go func() {
+		defer close(ch)
+		defer shared.Recover(ctx, ch)
+
+		streamer := completion.NewStreamer(in)
+		// Usage metadata is cumulative; the last one seen goes with the last chunk
+		var usage *shared.Usage
+		// Returning stops the iterator, which cancels the upstream stream
+		for chunk, err := range client.Models.GenerateContentStream(ctx, model, genai.Text(user), config) {
+			if err != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: ClassifyError(err)})
+				return
+			}
+			if blocked := BlockedError(chunk); blocked != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: blocked})
+				return
+			}
+			if u := Usage(chunk.UsageMetadata); u != nil {
+				usage = u
+			}
+
+			delta, done := streamer.Write(chunk.Text())
+			if delta != "" || done {
+				out := shared.ChunkData{Content: delta, IsLast: done}
+				if done {
+					out.Usage = usage
+				}
+				if !shared.Send(ctx, ch, out) {
+					return
+				}
+			}
+			if done {
+				return
+			}
+		}
+		shared.Send(ctx, ch, shared.ChunkData{Content: streamer.Flush(), IsLast: true, Usage: usage})
+	}()
+	return ch, nil
+}
+
+// completionConfig asks for a short, mostly deterministic completion.
+func completionConfig(system string) *genai.GenerateContentConfig {
+	return &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(system, genai.RoleUser),
+		Temperature:       genai.Ptr[float32](0.2),
+		MaxOutputTokens:   maxCompletionTokens,
+	}
+}
@@ -0,0 +1,96 @@
+package googleai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"golang.org/x/net/proxy"
+	"google.golang.org/genai"
+)
+
+// Config holds the settings shared by the plugins that talk to the Gemini API.
+type Config struct {
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// LoadConfig reads the Gemini API key and proxy settings from the plugin's
+// config sub-tree, then the app config, then the GOOGLE_API_KEY and
+// https_proxy environment variables. It returns an error instead of exiting
+// so the host can refuse the plugin.
+func LoadConfig(conf shared.PluginConfig) (*Config, error) {
+	apiKey := conf.GetString("api-key")
+	if apiKey == "" {
+		apiKey = cfg.GetAppConfig().GetString("services.gemini.api-key")
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("GOOGLE_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, errors.New("gemini api key not configured: set services.gemini.api-key or GOOGLE_API_KEY")
+	}
+
+	proxyUrl := conf.GetString("proxy-url")
+	if proxyUrl == "" {
+		proxyUrl = cfg.GetAppConfig().GetString("app.proxy-url")
+	}
+	if proxyUrl == "" {
+		proxyUrl = os.Getenv("https_proxy")
+	}
+
+	httpClient, err := newHTTPClient(proxyUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{APIKey: apiKey, HTTPClient: httpClient}, nil
+}
+
+// NewClient creates a Gemini API client from the config.
+func (c *Config) NewClient(ctx context.Context) (*genai.Client, error) {
+	return genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:     c.APIKey,
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: c.HTTPClient,
+	})
+}
+
+// Health checks that the API key is accepted and the model is reachable.
+func Health(ctx context.Context, client *genai.Client, model string) error {
+	if client == nil {
+		return errors.New("plugin not initialized")
+	}
+	if _, err := client.Models.Get(ctx, model, nil); err != nil {
+		return ClassifyError(err)
+	}
+	return nil
+}
+
+func newHTTPClient(proxyUrl string) (*http.Client, error) {
+	if len(proxyUrl) == 0 {
+		return &http.Client{}, nil
+	}
+
+	// SOCKS proxy address
+	proxyURL, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %q: %w", proxyUrl, err)
+	}
+
+	// Create a SOCKS5 dialer
+	dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported proxy url %q: %w", proxyUrl, err)
+	}
+
+	// Create HTTP client with the SOCKS5 dialer
+	httpTransport := &http.Transport{
+		Dial: dialer.Dial,
+	}
+	return &http.Client{Transport: httpTransport}, nil
+}
@@ -0,0 +1,73 @@
+package googleai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/genai"
+)
+
+// Conn holds a plugin's Gemini client. Every instance of a plugin shares its
+// package state, so calls take the client with Acquire and Close waits for
+// them to finish instead of pulling the client from under a running call.
+type Conn struct {
+	mu         sync.RWMutex
+	client     *genai.Client
+	httpClient *http.Client
+	inflight   *sync.WaitGroup // calls on the current client
+}
+
+// Set installs a newly created client.
+func (c *Conn) Set(client *genai.Client, httpClient *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client, c.httpClient, c.inflight = client, httpClient, &sync.WaitGroup{}
+}
+
+// Acquire returns the client for one call. The caller must run release once
+// the call is done. It fails once the plugin has been closed.
+func (c *Conn) Acquire() (client *genai.Client, release func(), err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.client == nil {
+		return nil, nil, shared.Errorf(shared.ErrUnavailable, "plugin not initialized")
+	}
+	c.inflight.Add(1)
+	return c.client, c.inflight.Done, nil
+}
+
+// Close stops new calls, waits for running ones and drops idle connections.
+func (c *Conn) Close() {
+	c.mu.Lock()
+	httpClient, inflight := c.httpClient, c.inflight
+	c.client, c.httpClient, c.inflight = nil, nil, nil
+	c.mu.Unlock()
+
+	if inflight != nil {
+		inflight.Wait()
+	}
+	if httpClient != nil {
+		httpClient.CloseIdleConnections()
+	}
+}
+
+// Hold forwards a stream and runs release once it has ended, so the call
+// counts as running until its last chunk.
+func Hold(ctx context.Context, in <-chan shared.ChunkData, release func()) <-chan shared.ChunkData {
+	out := make(chan shared.ChunkData)
+	go func() {
+		defer release()
+		defer close(out)
+		for chunk := range in {
+			if !shared.Send(ctx, out, chunk) {
+				break
+			}
+		}
+		// The producer sees ctx too; wait for it to close its channel
+		for range in {
+		}
+	}()
+	return out
+}
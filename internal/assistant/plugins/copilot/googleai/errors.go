@@ -0,0 +1,41 @@
+package googleai
+
+import (
+	"errors"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/genai"
+)
+
+// ClassifyError converts an error returned by the genai client (possibly
+// wrapped by eino) into a typed plugin error.
+func ClassifyError(err error) *shared.PluginError {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return shared.NewError(shared.KindFromHTTPStatus(apiErr.Code), err)
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil {
+		return shared.NewError(shared.KindFromHTTPStatus(apiErrPtr.Code), err)
+	}
+	return shared.AsPluginError(err)
+}
+
+// BlockedError reports whether a response was blocked by Gemini's safety
+// filters, either for the prompt or for the generated candidate.
+func BlockedError(resp *genai.GenerateContentResponse) *shared.PluginError {
+	if resp == nil {
+		return nil
+	}
+	if fb := resp.PromptFeedback; fb != nil && fb.BlockReason != "" && fb.BlockReason != genai.BlockedReasonUnspecified {
+		return shared.Errorf(shared.ErrContentBlocked, "prompt blocked: %s", fb.BlockReason)
+	}
+	for _, c := range resp.Candidates {
+		switch c.FinishReason {
+		case genai.FinishReasonSafety, genai.FinishReasonBlocklist, genai.FinishReasonProhibitedContent,
+			genai.FinishReasonSPII, genai.FinishReasonRecitation:
+			return shared.Errorf(shared.ErrContentBlocked, "response blocked: %s", c.FinishReason)
+		}
+	}
+	return nil
+}
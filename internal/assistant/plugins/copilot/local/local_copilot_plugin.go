@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/qtopie/homa/internal/assistant/completion"
+	"github.com/qtopie/homa/internal/assistant/llm"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/rpcplugin"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+const (
+	defaultBaseURL   = "http://localhost:11434/v1" // Ollama
+	chatSystemPrompt = "You are a helpful programming assistant. Answer concisely and use Markdown code blocks for code."
+)
+
+var (
+	httpClient          *http.Client
+	chatModel           *llm.HomaChatModel
+	completionModel     *llm.HomaChatModel
+	maxCompletionTokens int
+
+	// fimFormat is the completion model's native fill-in-the-middle format,
+	// or nil to prompt it as a chat model
+	fimFormat *completion.Format
+)
+
+// LocalCopilotPlugin runs chat and completions on a self-hosted model server
+// that speaks the OpenAI-compatible API, such as Ollama, vLLM or the
+// llama.cpp server, so code never leaves the machine.
+type LocalCopilotPlugin struct{}
+
+// Init creates the model clients from the [plugins.local] section.
+func (p LocalCopilotPlugin) Init(config shared.PluginConfig) error {
+	chatName := config.GetString("chat-model")
+	if chatName == "" {
+		return errors.New("chat-model is required")
+	}
+	completionName := config.GetString("completion-model")
+	if completionName == "" {
+		completionName = chatName
+	}
+	baseURL := config.GetString("base-url")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	client := &http.Client{}
+	newModel := func(name string) (*llm.HomaChatModel, error) {
+		return llm.NewHomaChatModel(&llm.HomaChatModelConfig{
+			APIKey:     config.GetString("api-key"),
+			BaseURL:    baseURL,
+			Model:      name,
+			Timeout:    config.GetDuration("timeout", 60*time.Second),
+			RetryCount: config.GetInt("retry-count", 1),
+			HTTPClient: client,
+		})
+	}
+	chat, err := newModel(chatName)
+	if err != nil {
+		return err
+	}
+	complete, err := newModel(completionName)
+	if err != nil {
+		return err
+	}
+
+	format := completion.FormatFor(completionName)
+	switch name := config.GetString("fim-format"); name {
+	case "":
+	case "none":
+		format = nil
+	default:
+		if format = completion.LookupFormat(name); format == nil {
+			return fmt.Errorf("unknown fim-format %q", name)
+		}
+	}
+
+	httpClient, chatModel, completionModel, fimFormat = client, chat, complete, format
+	maxCompletionTokens = config.GetInt("max-completion-tokens", 128)
+	return nil
+}
+
+// Health checks that the model server answers.
+func (p LocalCopilotPlugin) Health(ctx context.Context) error {
+	if chatModel == nil {
+		return errors.New("plugin not initialized")
+	}
+	if err := chatModel.Ping(ctx); err != nil {
+		return classifyError(err)
+	}
+	return nil
+}
+
+// Close drops the idle connections to the model server. The models stay
+// set, since every instance of the plugin shares them and calls may still be
+// running on them.
+func (p LocalCopilotPlugin) Close() error {
+	if httpClient != nil {
+		httpClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Chat streams the model's reply to the message, with the session history as
+// preceding turns.
+func (p LocalCopilotPlugin) Chat(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	messages := []*schema.Message{schema.SystemMessage(chatSystemPrompt)}
+	for _, m := range req.History {
+		switch m.Role {
+		case "user":
+			messages = append(messages, schema.UserMessage(m.Content))
+		case "assistant":
+			messages = append(messages, schema.AssistantMessage(m.Content, nil))
+		}
+	}
+	messages = append(messages, schema.UserMessage(chatPrompt(req)))
+
+	stream, err := chatModel.Stream(ctx, messages)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+
+	ch := make(chan shared.ChunkData)
+	go func() {
+		defer close(ch)
+		defer shared.Recover(ctx, ch)
+		defer stream.Close()
+
+		for {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: classifyError(err)})
+				return
+			}
+			chunk := shared.ChunkData{Content: msg.Content, Reasoning: msg.ReasoningContent}
+			if meta := msg.ResponseMeta; meta != nil {
+				// The usage arrives in a chunk of its own after the finish reason
+				chunk.FinishReason = shared.ParseFinishReason(meta.FinishReason)
+				chunk.Usage = toUsage(meta.Usage)
+			}
+			if chunk.Content == "" && chunk.Reasoning == "" && chunk.FinishReason == "" && chunk.Usage == nil {
+				continue
+			}
+			if !shared.Send(ctx, ch, chunk) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// chatPrompt adds the file the user is looking at to the message.
+func chatPrompt(req shared.UserRequest) string {
+	if req.Filename == "" {
+		return req.Message
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\nI am editing %s", req.Message, req.Filename)
+	if req.FrontPart != "" || req.BackPart != "" {
+		fmt.Fprintf(&b, ":\n```\n%s%s\n```", req.FrontPart, req.BackPart)
+	}
+	return b.String()
+}
+
+// AutoComplete fills in the code between FrontPart and BackPart, with the
+// model's native FIM tokens when it has them.
+func (p LocalCopilotPlugin) AutoComplete(ctx context.Context, req shared.UserRequest) (string, error) {
+	in := completion.NewInput(req)
+	if in.Empty() {
+		return "", shared.Errorf(shared.ErrInvalidRequest, "nothing to complete")
+	}
+	opts := []model.Option{model.WithTemperature(0.2), model.WithMaxTokens(maxCompletionTokens)}
+
+	var out string
+	var usage *schema.TokenUsage
+	if fimFormat != nil {
+		usage = &schema.TokenUsage{}
+		text, err := completionModel.Complete(ctx, fimFormat.Prompt(in),
+			append(opts, model.WithStop(fimFormat.StopSequences(in)), llm.WithUsage(usage))...)
+		if err != nil {
+			return "", classifyError(err)
+		}
+		out = text
+	} else {
+		system, user := completion.ChatPrompt(in)
+		result, err := completionModel.Generate(ctx, []*schema.Message{
+			schema.SystemMessage(system),
+			schema.UserMessage(user),
+		}, opts...)
+		if err != nil {
+			return "", classifyError(err)
+		}
+		out = result.Content
+		if result.ResponseMeta != nil {
+			usage = result.ResponseMeta.Usage
+		}
+	}
+	if u := toUsage(usage); u != nil {
+		shared.ReportUsage(ctx, *u)
+	}
+	return completion.PostProcess(in, out), nil
+}
+
+// AutoCompleteStream streams the completion as the model generates it and
+// stops the generation once it reaches the end of its line or block.
+func (p LocalCopilotPlugin) AutoCompleteStream(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	in := completion.NewInput(req)
+	if in.Empty() {
+		return nil, shared.Errorf(shared.ErrInvalidRequest, "nothing to complete")
+	}
+	opts := []model.Option{model.WithTemperature(0.2), model.WithMaxTokens(maxCompletionTokens)}
+
+	// The usage arrives at the end of the stream, so it is unknown when the
+	// completion is cut short
+	usage := &schema.TokenUsage{}
+	var stream *schema.StreamReader[string]
+	if fimFormat != nil {
+		s, err := completionModel.CompleteStream(ctx, fimFormat.Prompt(in),
+			append(opts, model.WithStop(fimFormat.StopSequences(in)), llm.WithUsage(usage))...)
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		stream = s
+	} else {
+		system, user := completion.ChatPrompt(in)
+		s, err := completionModel.Stream(ctx, []*schema.Message{
+			schema.SystemMessage(system),
+			schema.UserMessage(user),
+		}, opts...)
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		stream = schema.StreamReaderWithConvert(s, func(msg *schema.Message) (string, error) {
+			if msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+				*usage = *msg.ResponseMeta.Usage
+			}
+			return msg.Content, nil
+		})
+	}
+
+	ch := make(chan shared.ChunkData)
+	go func() {
+		defer close(ch)
+		defer shared.Recover(ctx, ch)
+		// Closing the reader ends the upstream request
+		defer stream.Close()
+
+		streamer := completion.NewStreamer(in)
+		for {
+			text, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: classifyError(err)})
+				return
+			}
+
+			delta, done := streamer.Write(text)
+			if delta != "" || done {
+				if !shared.Send(ctx, ch, shared.ChunkData{Content: delta, IsLast: done}) {
+					return
+				}
+			}
+			if done {
+				return
+			}
+		}
+		shared.Send(ctx, ch, shared.ChunkData{Content: streamer.Flush(), IsLast: true, Usage: toUsage(usage)})
+	}()
+	return ch, nil
+}
+
+// toUsage converts the usage reported by the model server, or returns nil if
+// it reported none.
+func toUsage(u *schema.TokenUsage) *shared.Usage {
+	if u == nil || u.TotalTokens == 0 && u.PromptTokens == 0 {
+		return nil
+	}
+	return &shared.Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		CachedTokens:     u.PromptTokenDetails.CachedTokens,
+	}
+}
+
+// classifyError converts a model client error into a typed plugin error.
+// A server that is down or unreachable is reported as unavailable, so the
+// host can fall back to another plugin.
+func classifyError(err error) *shared.PluginError {
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		return shared.NewError(shared.KindFromHTTPStatus(apiErr.StatusCode), err)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return shared.AsPluginError(err)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// The server dropped the connection part way through the reply
+		return shared.NewError(shared.ErrUnavailable, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return shared.NewError(shared.ErrTimeout, err)
+		}
+		return shared.NewError(shared.ErrUnavailable, err)
+	}
+	return shared.AsPluginError(err)
+}
+
+// Export the plugin instance
+var Plugin LocalCopilotPlugin
+
+var _ shared.PluginLifecycle = Plugin
+var _ shared.StreamingCompleter = Plugin
+
+// main serves the plugin as a standalone process for the process mode
+func main() {
+	rpcplugin.Serve(Plugin)
+}
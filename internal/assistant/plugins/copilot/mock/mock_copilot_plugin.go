@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/rpcplugin"
 	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
 )
 
@@ -11,20 +14,50 @@ import (
 type MockCopilotPlugin struct{}
 
 // Chat simulates streaming data chunks to the client
-func (p MockCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData, error) {
+func (p MockCopilotPlugin) Chat(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
 	ch := make(chan shared.ChunkData)
 
 	go func() {
 		defer close(ch) // Ensure the channel is closed when done
+		defer shared.Recover(ctx, ch)
+
+		// Simulate a tool call for messages asking for one
+		if strings.Contains(req.Message, "tool") {
+			call := shared.ToolCall{ID: "mock-1", Name: "echo", Arguments: fmt.Sprintf(`{"text":%q}`, req.Message)}
+			approved, err := shared.ApproveTool(ctx, call)
+			if err != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: shared.AsPluginError(err)})
+				return
+			}
+			event := shared.ToolEvent{Call: call, Done: true, Denied: !approved}
+			if approved {
+				event.Result = req.Message
+			}
+			if !shared.Send(ctx, ch, shared.ChunkData{Tool: &event}) {
+				return
+			}
+		}
 
 		// Simulate sending 5 chunks of data
 		for i := 1; i <= 5; i++ {
-			ch <- shared.ChunkData{
+			chunk := shared.ChunkData{
 				ID:      fmt.Sprintf("%d", i),
 				Content: fmt.Sprintf("Chunk %d: %s", i, req.Message),
 				IsLast:  i == 5, // Mark the last chunk
 			}
-			time.Sleep(500 * time.Millisecond) // Simulate delay
+			if chunk.IsLast {
+				chunk.FinishReason = shared.FinishStop
+				chunk.Usage = mockUsage(req.Message, strings.Repeat("Chunk n: "+req.Message, 5))
+			}
+			if !shared.Send(ctx, ch, chunk) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(500 * time.Millisecond): // Simulate delay
+			}
 		}
 	}()
 
@@ -32,9 +65,73 @@ func (p MockCopilotPlugin) Chat(req shared.UserRequest) (<-chan shared.ChunkData
 }
 
 // AutoComplete simulates generating a single response
-func (p MockCopilotPlugin) AutoComplete(req shared.UserRequest) (string, error) {
-	return fmt.Sprintf("AutoComplete response for: %s", req.Message), nil
+func (p MockCopilotPlugin) AutoComplete(ctx context.Context, req shared.UserRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	reply := fmt.Sprintf("AutoComplete response for: %s", req.Message)
+	shared.ReportUsage(ctx, *mockUsage(req.Message, reply))
+	return reply, nil
+}
+
+// mockUsage counts words as tokens
+func mockUsage(prompt, reply string) *shared.Usage {
+	in, out := len(strings.Fields(prompt)), len(strings.Fields(reply))
+	return &shared.Usage{PromptTokens: in, CompletionTokens: out, TotalTokens: in + out}
+}
+
+// AutoCompleteCandidates simulates ranked candidates with falling scores
+func (p MockCopilotPlugin) AutoCompleteCandidates(ctx context.Context, req shared.UserRequest) ([]shared.Candidate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	candidates := make([]shared.Candidate, max(req.MaxCandidates, 1))
+	for i := range candidates {
+		candidates[i] = shared.Candidate{
+			Content: fmt.Sprintf("AutoComplete candidate %d for: %s", i+1, req.Message),
+			Score:   1 / float32(i+2),
+		}
+		shared.ReportUsage(ctx, *mockUsage(req.Message, candidates[i].Content))
+	}
+	return candidates, nil
+}
+
+// AutoCompleteStream simulates a completion generated word by word
+func (p MockCopilotPlugin) AutoCompleteStream(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	ch := make(chan shared.ChunkData)
+
+	go func() {
+		defer close(ch)
+		defer shared.Recover(ctx, ch)
+
+		words := strings.Fields(fmt.Sprintf("AutoComplete response for: %s", req.Message))
+		for i, word := range words {
+			if i > 0 {
+				word = " " + word
+			}
+			chunk := shared.ChunkData{Content: word, IsLast: i == len(words)-1}
+			if chunk.IsLast {
+				chunk.Usage = mockUsage(req.Message, strings.Join(words, " "))
+			}
+			if !shared.Send(ctx, ch, chunk) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(100 * time.Millisecond): // Simulate delay
+			}
+		}
+	}()
+
+	return ch, nil
 }
 
 // Export the mock plugin instance
 var Plugin MockCopilotPlugin
+
+// main serves the plugin as a standalone process for the process mode
+func main() {
+	rpcplugin.Serve(Plugin)
+}
@@ -0,0 +1,99 @@
+package rpcplugin
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/qtopie/homa/gen/pluginrpc"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// approvals holds the tool calls of a plugin process waiting for the host's
+// decision.
+type approvals struct {
+	mu      sync.Mutex
+	next    int
+	pending map[string]chan bool
+}
+
+// add registers a tool call and returns its id and the channel its decision
+// is delivered on.
+func (a *approvals) add() (string, <-chan bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pending == nil {
+		a.pending = make(map[string]chan bool)
+	}
+	a.next++
+	id := strconv.Itoa(a.next)
+	decision := make(chan bool, 1)
+	a.pending[id] = decision
+	return id, decision
+}
+
+func (a *approvals) remove(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.pending, id)
+}
+
+// resolve delivers the decision for id. It reports false if no call with
+// that id is waiting.
+func (a *approvals) resolve(id string, approved bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	decision, ok := a.pending[id]
+	if !ok {
+		return false
+	}
+	delete(a.pending, id)
+	decision <- approved
+	return true
+}
+
+// remoteApprover asks the host to approve tool calls with a chunk on the Chat
+// stream; the host answers through ResolveTool.
+type remoteApprover struct {
+	approvals *approvals
+	stream    grpc.ServerStreamingServer[pluginrpc.PluginChunk]
+}
+
+func (a *remoteApprover) ApproveTool(ctx context.Context, call shared.ToolCall) (bool, error) {
+	id, decision := a.approvals.add()
+	defer a.approvals.remove(id)
+
+	msg := &pluginrpc.PluginChunk{Tool: &pluginrpc.ToolEvent{Call: toToolCall(call), ApprovalId: id}}
+	if err := a.stream.Send(msg); err != nil {
+		return false, err
+	}
+	select {
+	case approved := <-decision:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (s *pluginServer) ResolveTool(ctx context.Context, req *pluginrpc.ToolDecision) (*pluginrpc.ToolDecisionResponse, error) {
+	if !s.approvals.resolve(req.ApprovalId, req.Approved) {
+		return nil, status.Errorf(codes.NotFound, "no tool call %q awaits approval", req.ApprovalId)
+	}
+	return &pluginrpc.ToolDecisionResponse{}, nil
+}
+
+// syncStream serializes sends on a plugin stream, which tool approvals make
+// alongside the forwarded chunks.
+type syncStream struct {
+	grpc.ServerStreamingServer[pluginrpc.PluginChunk]
+	mu sync.Mutex
+}
+
+func (s *syncStream) Send(msg *pluginrpc.PluginChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ServerStreamingServer.Send(msg)
+}
@@ -0,0 +1,399 @@
+package rpcplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/qtopie/homa/gen/pluginrpc"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// startTimeout bounds how long a plugin process may take to open its socket
+	startTimeout = 10 * time.Second
+
+	// initTimeout bounds the Init call made after every (re)start
+	initTimeout = 30 * time.Second
+
+	// stopTimeout is how long a plugin gets to exit after SIGTERM before it is killed
+	stopTimeout = 5 * time.Second
+
+	minRestartDelay = time.Second
+	maxRestartDelay = 30 * time.Second
+)
+
+// Client runs a copilot plugin executable as a supervised child process and
+// implements the host's plugin interfaces over its unix socket. A crashed
+// process is restarted with exponential backoff and initialized again with
+// the last config; requests fail with ErrUnavailable until it is back.
+type Client struct {
+	name string
+	path string
+
+	dir        string // private directory holding the socket
+	socketPath string
+	conn       *grpc.ClientConn
+	rpc        pluginrpc.CopilotPluginServiceClient
+
+	mu      sync.Mutex
+	config  shared.PluginConfig
+	proc    *process
+	stop    chan struct{}
+	stopped chan struct{} // closed once the supervisor has exited
+}
+
+// NewClient creates a client for the plugin executable at path. The process
+// is started by Init.
+func NewClient(name, path string) *Client {
+	return &Client{name: name, path: path}
+}
+
+// Init starts the plugin process if it is not running yet and hands it its
+// config.
+func (c *Client) Init(config shared.PluginConfig) error {
+	c.mu.Lock()
+	c.config = config
+	running := c.proc != nil
+	c.mu.Unlock()
+
+	if !running {
+		if err := c.open(); err != nil {
+			return err
+		}
+		proc, err := c.startProcess()
+		if err != nil {
+			c.cleanup()
+			return err
+		}
+
+		c.mu.Lock()
+		c.proc = proc
+		c.stop = make(chan struct{})
+		c.stopped = make(chan struct{})
+		c.mu.Unlock()
+		go c.supervise(proc)
+	}
+	return c.initRemote()
+}
+
+// open prepares the socket directory and the gRPC connection, which
+// reconnects on its own when the process is restarted.
+func (c *Client) open() error {
+	dir, err := os.MkdirTemp("", "homa-plugin-"+c.name+"-")
+	if err != nil {
+		return err
+	}
+	socketPath := filepath.Join(dir, "plugin.sock")
+
+	conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	c.dir, c.socketPath, c.conn = dir, socketPath, conn
+	c.rpc = pluginrpc.NewCopilotPluginServiceClient(conn)
+	return nil
+}
+
+func (c *Client) cleanup() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	if c.dir != "" {
+		os.RemoveAll(c.dir)
+	}
+}
+
+// process is a running plugin executable; exited receives the result of Wait.
+type process struct {
+	cmd     *exec.Cmd
+	started time.Time
+	exited  chan error
+}
+
+// startProcess launches the executable and waits until it listens on the socket.
+func (c *Client) startProcess() (*process, error) {
+	_ = os.Remove(c.socketPath)
+
+	cmd := exec.Command(c.path)
+	cmd.Env = append(os.Environ(), SocketEnv+"="+c.socketPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", c.name, err)
+	}
+
+	p := &process{cmd: cmd, started: time.Now(), exited: make(chan error, 1)}
+	go func() {
+		p.exited <- cmd.Wait()
+	}()
+
+	deadline := time.After(startTimeout)
+	for {
+		if _, err := os.Stat(c.socketPath); err == nil {
+			return p, nil
+		}
+		select {
+		case err := <-p.exited:
+			return nil, fmt.Errorf("plugin %s exited during startup: %v", c.name, err)
+		case <-deadline:
+			_ = cmd.Process.Kill()
+			<-p.exited
+			return nil, fmt.Errorf("plugin %s did not open its socket within %s", c.name, startTimeout)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func (c *Client) initRemote() error {
+	c.mu.Lock()
+	config := c.config
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), initTimeout)
+	defer cancel()
+	if _, err := c.rpc.Init(ctx, &pluginrpc.InitRequest{Config: toConfigMap(config)}, grpc.WaitForReady(true)); err != nil {
+		return fmt.Errorf("plugin %s failed to initialize: %w", c.name, statusError(err))
+	}
+	return nil
+}
+
+// supervise restarts the plugin process whenever it exits until Close is called.
+func (c *Client) supervise(proc *process) {
+	defer close(c.stopped)
+
+	delay := minRestartDelay
+	for {
+		err := <-proc.exited
+
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		// A process that ran for a while is not crash-looping
+		if time.Since(proc.started) > time.Minute {
+			delay = minRestartDelay
+		}
+		log.Printf("Plugin process %s exited (%v), restarting in %s", c.name, err, delay)
+
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxRestartDelay)
+
+			proc, err = c.startProcess()
+			if err == nil {
+				break
+			}
+			log.Printf("Failed to restart plugin process %s: %v", c.name, err)
+		}
+
+		c.mu.Lock()
+		select {
+		case <-c.stop:
+			// Closed while restarting
+			c.mu.Unlock()
+			_ = proc.cmd.Process.Kill()
+			<-proc.exited
+			return
+		default:
+		}
+		c.proc = proc
+		c.mu.Unlock()
+		if err := c.initRemote(); err != nil {
+			log.Printf("Restarted plugin process %s failed to initialize: %v", c.name, err)
+		}
+	}
+}
+
+// Health asks the plugin process to probe its backend.
+func (c *Client) Health(ctx context.Context) error {
+	if c.rpc == nil {
+		return errors.New("plugin process not started")
+	}
+	if _, err := c.rpc.Health(ctx, &pluginrpc.HealthRequest{}); err != nil {
+		return statusError(err)
+	}
+	return nil
+}
+
+// Close stops the supervisor and the plugin process.
+func (c *Client) Close() error {
+	// Closing stop under the lock means the supervisor either sees it before
+	// installing a restarted process, or installed it before our snapshot
+	c.mu.Lock()
+	proc, stopped := c.proc, c.stopped
+	if proc == nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.proc = nil
+	close(c.stop)
+	c.mu.Unlock()
+
+	_ = proc.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		_ = proc.cmd.Process.Kill()
+		<-stopped
+	}
+	c.cleanup()
+	return nil
+}
+
+// Chat streams the plugin's reply. A broken connection to the process ends
+// the stream with an ErrUnavailable chunk. When ctx has a tool approver, the
+// plugin's tool calls are passed to it before they run.
+func (c *Client) Chat(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	msg := toPluginRequest(req)
+	msg.ApproveTools = shared.ToolApproverFrom(ctx) != nil
+	stream, err := c.rpc.Chat(ctx, msg)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return c.receiveChunks(ctx, stream, nil), nil
+}
+
+// AutoCompleteStream streams the plugin's completion. Plugin binaries built
+// before the streaming RPC existed answer through AutoComplete instead.
+func (c *Client) AutoCompleteStream(ctx context.Context, req shared.UserRequest) (<-chan shared.ChunkData, error) {
+	stream, err := c.rpc.AutoCompleteStream(ctx, toPluginRequest(req))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	unary := func() shared.ChunkData {
+		reply, err := c.AutoComplete(ctx, req)
+		if err != nil {
+			return shared.ChunkData{IsLast: true, Err: shared.AsPluginError(err)}
+		}
+		return shared.ChunkData{Content: reply, IsLast: true}
+	}
+	return c.receiveChunks(ctx, stream, unary), nil
+}
+
+// receiveChunks forwards the chunks of a plugin stream. A broken connection
+// ends it with an ErrUnavailable chunk; if the plugin does not implement the
+// RPC, the single chunk returned by unimplemented is sent instead. Tool calls
+// waiting for approval are answered on the way.
+func (c *Client) receiveChunks(ctx context.Context, stream grpc.ServerStreamingClient[pluginrpc.PluginChunk], unimplemented func() shared.ChunkData) <-chan shared.ChunkData {
+	ch := make(chan shared.ChunkData)
+	go func() {
+		defer close(ch)
+		for first := true; ; first = false {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if first && unimplemented != nil && status.Code(err) == codes.Unimplemented {
+				shared.Send(ctx, ch, unimplemented())
+				return
+			}
+			if err != nil {
+				shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: statusError(err)})
+				return
+			}
+
+			if msg.Tool != nil && msg.Tool.ApprovalId != "" {
+				if err := c.resolveTool(ctx, msg.Tool); err != nil {
+					shared.Send(ctx, ch, shared.ChunkData{IsLast: true, Err: statusError(err)})
+					return
+				}
+				continue
+			}
+
+			if !shared.Send(ctx, ch, fromPluginChunk(msg)) {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// resolveTool asks the approver of ctx about a tool call the plugin wants to
+// make and passes the decision back. A failing approver denies the call.
+func (c *Client) resolveTool(ctx context.Context, event *pluginrpc.ToolEvent) error {
+	approved, err := shared.ApproveTool(ctx, fromToolCall(event.Call))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Denying tool call %s of plugin %s: %v", event.GetCall().GetName(), c.name, err)
+		approved = false
+	}
+	_, err = c.rpc.ResolveTool(ctx, &pluginrpc.ToolDecision{ApprovalId: event.ApprovalId, Approved: approved})
+	return err
+}
+
+// AutoComplete returns the plugin's completion.
+func (c *Client) AutoComplete(ctx context.Context, req shared.UserRequest) (string, error) {
+	resp, err := c.rpc.AutoComplete(ctx, toPluginRequest(req))
+	if err != nil {
+		return "", statusError(err)
+	}
+	reportUsage(ctx, resp.Usage)
+	if resp.Error != nil {
+		return "", fromPluginError(resp.Error)
+	}
+	return resp.Content, nil
+}
+
+// AutoCompleteCandidates returns the plugin's completions. Plugins that
+// offer a single one answer with just its content.
+func (c *Client) AutoCompleteCandidates(ctx context.Context, req shared.UserRequest) ([]shared.Candidate, error) {
+	resp, err := c.rpc.AutoComplete(ctx, toPluginRequest(req))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	reportUsage(ctx, resp.Usage)
+	if resp.Error != nil {
+		return nil, fromPluginError(resp.Error)
+	}
+	if len(resp.Candidates) == 0 {
+		return []shared.Candidate{{Content: resp.Content}}, nil
+	}
+	return fromPluginCandidates(resp.Candidates), nil
+}
+
+// reportUsage passes the usage of a unary call on to the reporter of ctx.
+func reportUsage(ctx context.Context, u *pluginrpc.Usage) {
+	if usage := fromPluginUsage(u); usage != nil {
+		shared.ReportUsage(ctx, *usage)
+	}
+}
+
+// statusError classifies a transport-level error from the plugin connection.
+func statusError(err error) *shared.PluginError {
+	st, ok := status.FromError(err)
+	if !ok {
+		return shared.AsPluginError(err)
+	}
+	switch st.Code() {
+	case codes.Unavailable:
+		return shared.Errorf(shared.ErrUnavailable, "plugin process unavailable: %s", st.Message())
+	case codes.DeadlineExceeded:
+		return shared.Errorf(shared.ErrTimeout, "%s", st.Message())
+	case codes.Canceled:
+		return shared.Errorf(shared.ErrCanceled, "%s", st.Message())
+	}
+	return shared.Errorf(shared.ErrInternal, "%s", st.Message())
+}
@@ -0,0 +1,206 @@
+package rpcplugin
+
+import (
+	"fmt"
+
+	"github.com/qtopie/homa/gen/pluginrpc"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+func toPluginRequest(req shared.UserRequest) *pluginrpc.PluginRequest {
+	history := make([]*pluginrpc.HistoryMessage, 0, len(req.History))
+	for _, m := range req.History {
+		history = append(history, &pluginrpc.HistoryMessage{Role: m.Role, Content: m.Content, Time: m.Time})
+	}
+	return &pluginrpc.PluginRequest{
+		SessionId:     req.SessionId,
+		Seq:           req.Seq,
+		Message:       req.Message,
+		FrontPart:     req.FrontPart,
+		BackPart:      req.BackPart,
+		Filename:      req.Filename,
+		Workspace:     req.Workspace,
+		History:       history,
+		MaxCandidates: int32(req.MaxCandidates),
+	}
+}
+
+func fromPluginRequest(req *pluginrpc.PluginRequest) shared.UserRequest {
+	history := make([]shared.Message, 0, len(req.History))
+	for _, m := range req.History {
+		history = append(history, shared.Message{Role: m.Role, Content: m.Content, Time: m.Time})
+	}
+	return shared.UserRequest{
+		SessionId:     req.SessionId,
+		Seq:           req.Seq,
+		Message:       req.Message,
+		FrontPart:     req.FrontPart,
+		BackPart:      req.BackPart,
+		Filename:      req.Filename,
+		Workspace:     req.Workspace,
+		History:       history,
+		MaxCandidates: int(req.MaxCandidates),
+	}
+}
+
+func toPluginCandidates(candidates []shared.Candidate) []*pluginrpc.PluginCandidate {
+	out := make([]*pluginrpc.PluginCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		out = append(out, &pluginrpc.PluginCandidate{
+			Content:       c.Content,
+			ReplaceBefore: int32(c.ReplaceBefore),
+			ReplaceAfter:  int32(c.ReplaceAfter),
+			Score:         c.Score,
+		})
+	}
+	return out
+}
+
+func fromPluginCandidates(candidates []*pluginrpc.PluginCandidate) []shared.Candidate {
+	out := make([]shared.Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		out = append(out, shared.Candidate{
+			Content:       c.Content,
+			ReplaceBefore: int(c.ReplaceBefore),
+			ReplaceAfter:  int(c.ReplaceAfter),
+			Score:         c.Score,
+		})
+	}
+	return out
+}
+
+func toPluginChunk(chunk shared.ChunkData) *pluginrpc.PluginChunk {
+	msg := &pluginrpc.PluginChunk{
+		Id:           chunk.ID,
+		Content:      chunk.Content,
+		IsLast:       chunk.IsLast,
+		Tool:         toToolEvent(chunk.Tool),
+		Reasoning:    chunk.Reasoning,
+		FinishReason: string(chunk.FinishReason),
+	}
+	if chunk.Err != nil {
+		msg.Error = toPluginError(chunk.Err)
+	}
+	for _, c := range chunk.Citations {
+		msg.Citations = append(msg.Citations, &pluginrpc.Citation{
+			Uri:        c.URI,
+			Title:      c.Title,
+			StartIndex: int32(c.StartIndex),
+			EndIndex:   int32(c.EndIndex),
+		})
+	}
+	msg.Usage = toPluginUsage(chunk.Usage)
+	return msg
+}
+
+func fromPluginChunk(msg *pluginrpc.PluginChunk) shared.ChunkData {
+	chunk := shared.ChunkData{
+		ID:           msg.Id,
+		Content:      msg.Content,
+		IsLast:       msg.IsLast,
+		Err:          fromPluginError(msg.Error),
+		Tool:         fromToolEvent(msg.Tool),
+		Reasoning:    msg.Reasoning,
+		FinishReason: shared.FinishReason(msg.FinishReason),
+	}
+	for _, c := range msg.Citations {
+		chunk.Citations = append(chunk.Citations, shared.Citation{
+			URI:        c.Uri,
+			Title:      c.Title,
+			StartIndex: int(c.StartIndex),
+			EndIndex:   int(c.EndIndex),
+		})
+	}
+	chunk.Usage = fromPluginUsage(msg.Usage)
+	return chunk
+}
+
+func toPluginUsage(u *shared.Usage) *pluginrpc.Usage {
+	if u == nil {
+		return nil
+	}
+	return &pluginrpc.Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+		CachedTokens:     int32(u.CachedTokens),
+	}
+}
+
+func fromPluginUsage(u *pluginrpc.Usage) *shared.Usage {
+	if u == nil {
+		return nil
+	}
+	return &shared.Usage{
+		PromptTokens:     int(u.PromptTokens),
+		CompletionTokens: int(u.CompletionTokens),
+		TotalTokens:      int(u.TotalTokens),
+		CachedTokens:     int(u.CachedTokens),
+	}
+}
+
+func toToolCall(call shared.ToolCall) *pluginrpc.ToolCall {
+	return &pluginrpc.ToolCall{Id: call.ID, Name: call.Name, Arguments: call.Arguments}
+}
+
+func fromToolCall(call *pluginrpc.ToolCall) shared.ToolCall {
+	if call == nil {
+		return shared.ToolCall{}
+	}
+	return shared.ToolCall{ID: call.Id, Name: call.Name, Arguments: call.Arguments}
+}
+
+func toToolEvent(event *shared.ToolEvent) *pluginrpc.ToolEvent {
+	if event == nil {
+		return nil
+	}
+	return &pluginrpc.ToolEvent{
+		Call:   toToolCall(event.Call),
+		Result: event.Result,
+		Done:   event.Done,
+		Denied: event.Denied,
+	}
+}
+
+func fromToolEvent(event *pluginrpc.ToolEvent) *shared.ToolEvent {
+	if event == nil {
+		return nil
+	}
+	return &shared.ToolEvent{
+		Call:   fromToolCall(event.Call),
+		Result: event.Result,
+		Done:   event.Done,
+		Denied: event.Denied,
+	}
+}
+
+func toPluginError(err error) *pluginrpc.PluginError {
+	if err == nil {
+		return nil
+	}
+	pe := shared.AsPluginError(err)
+	return &pluginrpc.PluginError{Kind: pluginrpc.ErrorKind(pe.Kind), Message: pe.Message}
+}
+
+func fromPluginError(err *pluginrpc.PluginError) *shared.PluginError {
+	if err == nil {
+		return nil
+	}
+	return &shared.PluginError{Kind: shared.ErrorKind(err.Kind), Message: err.Message}
+}
+
+func toConfigMap(config shared.PluginConfig) map[string]string {
+	m := make(map[string]string, len(config))
+	for k, v := range config {
+		m[k] = fmt.Sprint(v)
+	}
+	return m
+}
+
+func fromConfigMap(m map[string]string) shared.PluginConfig {
+	config := make(shared.PluginConfig, len(m))
+	for k, v := range m {
+		config[k] = v
+	}
+	return config
+}
@@ -0,0 +1,186 @@
+package rpcplugin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/qtopie/homa/gen/pluginrpc"
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc"
+)
+
+// SocketEnv names the environment variable holding the unix socket path a
+// plugin process must serve on.
+const SocketEnv = "HOMA_PLUGIN_SOCKET"
+
+// CopilotPlugin is the plugin interface served over the socket. It matches
+// the host's CopilotPlugin so the same plugin value works in both modes.
+type CopilotPlugin interface {
+	Chat(context.Context, shared.UserRequest) (<-chan shared.ChunkData, error)
+
+	AutoComplete(context.Context, shared.UserRequest) (string, error)
+}
+
+// Serve runs p as a standalone plugin process until SIGTERM or SIGINT. Plugin
+// packages call it from main so they can be built both with
+// -buildmode=plugin and as executables.
+func Serve(p CopilotPlugin) {
+	socketPath := os.Getenv(SocketEnv)
+	if socketPath == "" {
+		log.Fatalf("%s is not set; this binary is meant to be started by homa", SocketEnv)
+	}
+	// Plugins fall back to the app config for settings their section lacks
+	if err := cfg.Load(); err != nil {
+		log.Printf("App config not loaded: %v", err)
+	}
+
+	_ = os.Remove(socketPath)
+	lis, err := net.Listen("unix", socketPath)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", socketPath, err)
+	}
+
+	grpcServer := grpc.NewServer()
+	pluginrpc.RegisterCopilotPluginServiceServer(grpcServer, &pluginServer{p: p})
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigs
+		grpcServer.GracefulStop()
+	}()
+
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("Failed to serve plugin: %v", err)
+	}
+	if lifecycle, ok := p.(shared.PluginLifecycle); ok {
+		_ = lifecycle.Close()
+	}
+}
+
+// pluginServer adapts a CopilotPlugin to the CopilotPluginService.
+type pluginServer struct {
+	pluginrpc.UnimplementedCopilotPluginServiceServer
+	p         CopilotPlugin
+	approvals approvals
+}
+
+func (s *pluginServer) Init(ctx context.Context, req *pluginrpc.InitRequest) (*pluginrpc.InitResponse, error) {
+	if lifecycle, ok := s.p.(shared.PluginLifecycle); ok {
+		if err := lifecycle.Init(fromConfigMap(req.Config)); err != nil {
+			return nil, err
+		}
+	}
+	return &pluginrpc.InitResponse{}, nil
+}
+
+func (s *pluginServer) Health(ctx context.Context, req *pluginrpc.HealthRequest) (*pluginrpc.HealthResponse, error) {
+	if lifecycle, ok := s.p.(shared.PluginLifecycle); ok {
+		if err := lifecycle.Health(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &pluginrpc.HealthResponse{}, nil
+}
+
+func (s *pluginServer) Chat(req *pluginrpc.PluginRequest, stream pluginrpc.CopilotPluginService_ChatServer) error {
+	ctx := stream.Context()
+	// Approval requests are sent from the goroutine running the tool
+	synced := &syncStream{ServerStreamingServer: stream}
+	if req.ApproveTools {
+		ctx = shared.WithToolApprover(ctx, &remoteApprover{approvals: &s.approvals, stream: synced})
+	}
+	chunks, err := s.p.Chat(ctx, fromPluginRequest(req))
+	if err != nil {
+		return synced.Send(&pluginrpc.PluginChunk{IsLast: true, Error: toPluginError(err)})
+	}
+	return sendChunks(synced, chunks)
+}
+
+// sendChunks forwards a plugin's chunks to the host.
+func sendChunks(stream grpc.ServerStreamingServer[pluginrpc.PluginChunk], chunks <-chan shared.ChunkData) error {
+	for chunk := range chunks {
+		if err := stream.Send(toPluginChunk(chunk)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *pluginServer) AutoCompleteStream(req *pluginrpc.PluginRequest, stream pluginrpc.CopilotPluginService_AutoCompleteStreamServer) error {
+	completer, ok := s.p.(shared.StreamingCompleter)
+	if !ok {
+		resp, err := s.AutoComplete(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		return stream.Send(&pluginrpc.PluginChunk{Content: resp.Content, IsLast: true, Error: resp.Error, Usage: resp.Usage})
+	}
+
+	chunks, err := completer.AutoCompleteStream(stream.Context(), fromPluginRequest(req))
+	if err != nil {
+		return stream.Send(&pluginrpc.PluginChunk{IsLast: true, Error: toPluginError(err)})
+	}
+	return sendChunks(stream, chunks)
+}
+
+func (s *pluginServer) AutoComplete(ctx context.Context, req *pluginrpc.PluginRequest) (resp *pluginrpc.PluginCompletion, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = &pluginrpc.PluginCompletion{Error: toPluginError(fmt.Errorf("plugin panic: %v", r))}, nil
+		}
+	}()
+
+	// Usage the plugin reports is sent back with the completion
+	meter := &usageMeter{}
+	ctx = shared.WithUsageReporter(ctx, meter)
+
+	if completer, ok := s.p.(shared.CandidateCompleter); ok && req.MaxCandidates > 1 {
+		candidates, err := completer.AutoCompleteCandidates(ctx, fromPluginRequest(req))
+		if err != nil {
+			return &pluginrpc.PluginCompletion{Error: toPluginError(err), Usage: meter.total()}, nil
+		}
+		resp := &pluginrpc.PluginCompletion{Candidates: toPluginCandidates(candidates), Usage: meter.total()}
+		if len(candidates) > 0 {
+			resp.Content = candidates[0].Content
+		}
+		return resp, nil
+	}
+
+	reply, err := s.p.AutoComplete(ctx, fromPluginRequest(req))
+	if err != nil {
+		return &pluginrpc.PluginCompletion{Error: toPluginError(err), Usage: meter.total()}, nil
+	}
+	return &pluginrpc.PluginCompletion{Content: reply, Usage: meter.total()}, nil
+}
+
+// usageMeter collects the usage a plugin reports during a unary call.
+type usageMeter struct {
+	mu       sync.Mutex
+	usage    shared.Usage
+	reported bool
+}
+
+func (m *usageMeter) ReportUsage(u shared.Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Add(u)
+	m.reported = true
+}
+
+// total returns the reported usage, or nil if the plugin reported none.
+func (m *usageMeter) total() *pluginrpc.Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.reported {
+		return nil
+	}
+	return toPluginUsage(&m.usage)
+}
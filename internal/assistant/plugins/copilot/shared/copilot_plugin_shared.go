@@ -1,5 +1,10 @@
 package shared
 
+import (
+	"context"
+	"strings"
+)
+
 type UserRequest struct {
 	SessionId string `json:"-"`
 	Seq       int32 `json:"-"`
@@ -9,12 +14,85 @@ type UserRequest struct {
 	Filename  string
 	Workspace string
 	History   []Message `json:"history,omitempty"`
+	// MaxCandidates is the number of completions the client wants; plugins
+	// implementing CandidateCompleter may return up to that many
+	MaxCandidates int `json:"-"`
+}
+
+// Candidate is one of several completions offered for the cursor position.
+type Candidate struct {
+	Content string
+	// Characters before and after the cursor the content replaces
+	ReplaceBefore int
+	ReplaceAfter  int
+	// Score is the plugin's confidence between 0 and 1, or 0 if unknown
+	Score float32
+}
+
+// CandidateCompleter is an optional interface for copilot plugins that can
+// offer several completions at once, best first. The host falls back to
+// AutoComplete for plugins without it.
+type CandidateCompleter interface {
+	AutoCompleteCandidates(ctx context.Context, req UserRequest) ([]Candidate, error)
+}
+
+// StreamingCompleter is an optional interface for copilot plugins that can
+// stream a completion as it is generated. Chunks carry the next part of the
+// completion; the host falls back to AutoComplete for plugins without it.
+type StreamingCompleter interface {
+	AutoCompleteStream(ctx context.Context, req UserRequest) (<-chan ChunkData, error)
 }
 
 type ChunkData struct {
 	ID      string
 	Content string
 	IsLast  bool
+	// Err is set on the final chunk when the stream failed part way through.
+	Err *PluginError
+	// Tool is set on chunks reporting a tool call instead of content.
+	Tool *ToolEvent
+	// Reasoning is the model's thinking, shown apart from the reply's content.
+	Reasoning string
+	// Citations are sources backing the content streamed so far.
+	Citations []Citation
+	// Usage is the token usage of the whole reply, sent once it is known.
+	Usage *Usage
+	// FinishReason tells why generation stopped, on the chunk where it did.
+	FinishReason FinishReason
+}
+
+// FinishReason tells why a model stopped generating.
+type FinishReason string
+
+const (
+	FinishStop          FinishReason = "stop"           // natural end or stop sequence
+	FinishLength        FinishReason = "length"         // token limit reached
+	FinishContentFilter FinishReason = "content_filter" // cut by a safety filter
+)
+
+// ParseFinishReason maps the finish reasons reported by OpenAI-compatible
+// and Gemini APIs. Others are passed on lowercased.
+func ParseFinishReason(reason string) FinishReason {
+	switch strings.ToLower(reason) {
+	case "":
+		return ""
+	case "stop", "end_turn", "stop_sequence":
+		return FinishStop
+	case "length", "max_tokens":
+		return FinishLength
+	case "content_filter", "safety", "recitation", "blocklist", "prohibited_content", "spii":
+		return FinishContentFilter
+	}
+	return FinishReason(strings.ToLower(reason))
+}
+
+// Citation is a source the model quoted or relied on. The indexes locate the
+// cited part of the reply in bytes, when the model reports them.
+type Citation struct {
+	URI        string
+	Title      string
+	StartIndex int
+	EndIndex   int
 }
 
 type Message struct {
@@ -22,3 +100,14 @@ type Message struct {
 	Content string `json:"content"`
 	Time    int64  `json:"time"`
 }
+
+// Send delivers a chunk unless ctx is cancelled first. It reports whether the
+// chunk was sent; producers should stop and close their channel on false.
+func Send(ctx context.Context, ch chan<- ChunkData, chunk ChunkData) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case ch <- chunk:
+		return true
+	}
+}
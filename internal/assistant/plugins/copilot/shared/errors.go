@@ -0,0 +1,115 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorKind classifies a plugin failure so the host can report it to clients
+// without knowing which upstream API the plugin talks to.
+type ErrorKind int
+
+const (
+	ErrInternal ErrorKind = iota
+	ErrRateLimited
+	ErrAuth
+	ErrUnavailable
+	ErrTimeout
+	ErrContentBlocked
+	ErrInvalidRequest
+	ErrCanceled
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrRateLimited:
+		return "rate_limited"
+	case ErrAuth:
+		return "auth"
+	case ErrUnavailable:
+		return "unavailable"
+	case ErrTimeout:
+		return "timeout"
+	case ErrContentBlocked:
+		return "content_blocked"
+	case ErrInvalidRequest:
+		return "invalid_request"
+	case ErrCanceled:
+		return "canceled"
+	default:
+		return "internal"
+	}
+}
+
+// PluginError is the typed error plugins report, either as the error return
+// of AutoComplete or in the Err field of the final ChunkData of a stream.
+type PluginError struct {
+	Kind    ErrorKind
+	Message string
+
+	cause error
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+func (e *PluginError) Unwrap() error {
+	return e.cause
+}
+
+// NewError wraps err as a PluginError of the given kind.
+func NewError(kind ErrorKind, err error) *PluginError {
+	return &PluginError{Kind: kind, Message: err.Error(), cause: err}
+}
+
+// Errorf creates a PluginError of the given kind from a format string.
+func Errorf(kind ErrorKind, format string, args ...any) *PluginError {
+	return NewError(kind, fmt.Errorf(format, args...))
+}
+
+// AsPluginError returns err as a PluginError, classifying context errors and
+// falling back to ErrInternal for anything untyped.
+func AsPluginError(err error) *PluginError {
+	var pe *PluginError
+	if errors.As(err, &pe) {
+		return pe
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return NewError(ErrCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewError(ErrTimeout, err)
+	}
+	return NewError(ErrInternal, err)
+}
+
+// KindFromHTTPStatus maps an upstream HTTP status code to an ErrorKind.
+func KindFromHTTPStatus(code int) ErrorKind {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrAuth
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case code >= 500:
+		return ErrUnavailable
+	case code >= 400:
+		return ErrInvalidRequest
+	}
+	return ErrInternal
+}
+
+// Recover turns a panic in a plugin's streaming goroutine into a final error
+// chunk instead of crashing the host. Defer it after closing the channel:
+//
+//	defer close(ch)
+//	defer shared.Recover(ctx, ch)
+func Recover(ctx context.Context, ch chan<- ChunkData) {
+	if r := recover(); r != nil {
+		Send(ctx, ch, ChunkData{IsLast: true, Err: Errorf(ErrInternal, "plugin panic: %v", r)})
+	}
+}
@@ -0,0 +1,75 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PluginLifecycle is an optional interface for plugins that hold resources.
+// The host calls Init once after loading the plugin, Health before switching
+// traffic to it and periodically afterwards, and Close once it is retired.
+type PluginLifecycle interface {
+	// Init receives the plugin's config sub-tree ([plugins.<name>] in the
+	// config file) and must fail if required settings are missing.
+	Init(config PluginConfig) error
+
+	// Health reports whether the plugin can reach its backend.
+	Health(ctx context.Context) error
+
+	// Close releases clients and connections held by the plugin.
+	Close() error
+}
+
+// PluginConfig is the configuration sub-tree handed to PluginLifecycle.Init.
+// Values read from the INI config file are strings.
+type PluginConfig map[string]any
+
+// GetString returns the value for key, or "" if it is not set.
+func (c PluginConfig) GetString(key string) string {
+	v, ok := c[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// GetInt returns the value for key as an int, or def if it is unset or invalid.
+func (c PluginConfig) GetInt(key string, def int) int {
+	n, err := strconv.Atoi(c.GetString(key))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// GetFloat returns the value for key as a float64, or def if it is unset or
+// invalid.
+func (c PluginConfig) GetFloat(key string, def float64) float64 {
+	f, err := strconv.ParseFloat(c.GetString(key), 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+// GetBool returns the value for key as a bool such as "true", or def if it
+// is unset or invalid.
+func (c PluginConfig) GetBool(key string, def bool) bool {
+	b, err := strconv.ParseBool(c.GetString(key))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// GetDuration returns the value for key as a duration such as "30s", or def
+// if it is unset or invalid.
+func (c PluginConfig) GetDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(c.GetString(key))
+	if err != nil {
+		return def
+	}
+	return d
+}
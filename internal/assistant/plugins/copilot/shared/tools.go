@@ -0,0 +1,50 @@
+package shared
+
+import "context"
+
+// ToolCall is a tool invocation the model asked for.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON
+}
+
+// ToolEvent reports a tool call made while generating a reply. Plugins send
+// it in a chunk without content when the call starts, and again with Done set
+// once it has a result or was denied.
+type ToolEvent struct {
+	Call   ToolCall
+	Result string
+	Done   bool
+	Denied bool
+}
+
+// ToolApprover decides whether a plugin may run a tool call. The host puts one
+// into the request context when the client confirms tool calls itself.
+type ToolApprover interface {
+	ApproveTool(ctx context.Context, call ToolCall) (bool, error)
+}
+
+type toolApproverKey struct{}
+
+// WithToolApprover returns a context whose tool calls are decided by a.
+func WithToolApprover(ctx context.Context, a ToolApprover) context.Context {
+	return context.WithValue(ctx, toolApproverKey{}, a)
+}
+
+// ToolApproverFrom returns the approver of ctx, or nil if it has none.
+func ToolApproverFrom(ctx context.Context) ToolApprover {
+	a, _ := ctx.Value(toolApproverKey{}).(ToolApprover)
+	return a
+}
+
+// ApproveTool asks the approver of ctx whether call may run. Plugins call it
+// before running a tool. Without an approver every call is allowed, as tools
+// have always run for plain Chat requests.
+func ApproveTool(ctx context.Context, call ToolCall) (bool, error) {
+	a := ToolApproverFrom(ctx)
+	if a == nil {
+		return true, nil
+	}
+	return a.ApproveTool(ctx, call)
+}
@@ -0,0 +1,43 @@
+package shared
+
+import "context"
+
+// Usage counts the tokens a reply took.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// CachedTokens is the part of PromptTokens served from the model's
+	// context cache, which is usually billed at a lower price
+	CachedTokens int
+}
+
+// Add adds the counts of o to u.
+func (u *Usage) Add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.TotalTokens += o.TotalTokens
+	u.CachedTokens += o.CachedTokens
+}
+
+// UsageReporter receives the token usage of plugin calls. The host puts one
+// into the context of every plugin call to account for it.
+type UsageReporter interface {
+	ReportUsage(u Usage)
+}
+
+type usageReporterKey struct{}
+
+// WithUsageReporter returns a context whose usage is reported to r.
+func WithUsageReporter(ctx context.Context, r UsageReporter) context.Context {
+	return context.WithValue(ctx, usageReporterKey{}, r)
+}
+
+// ReportUsage reports the usage of a model call made for ctx. Plugins call it
+// from AutoComplete and AutoCompleteCandidates; streams report usage in their
+// chunks instead, and must not report it twice.
+func ReportUsage(ctx context.Context, u Usage) {
+	if r, ok := ctx.Value(usageReporterKey{}).(UsageReporter); ok {
+		r.ReportUsage(u)
+	}
+}
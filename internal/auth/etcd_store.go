@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/qtopie/homa/internal/etcdkv"
+)
+
+// EtcdStore keeps API tokens in etcd, keyed by their hash so that the
+// tokens themselves are never stored.
+type EtcdStore struct {
+	cli *clientv3.Client
+}
+
+func NewEtcdStore(endpoints []string) (*EtcdStore, error) {
+	cli, err := etcdkv.Connect(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdStore{cli: cli}, nil
+}
+
+func key(hash string) string {
+	return "/auth/tokens/" + hash
+}
+
+// Lookup returns the principal a token belongs to, or nil if it is unknown.
+func (s *EtcdStore) Lookup(ctx context.Context, token string) (*Principal, error) {
+	getResp, err := s.cli.Get(ctx, key(HashToken(token)))
+	if err != nil {
+		return nil, err
+	}
+	if len(getResp.Kvs) == 0 {
+		return nil, nil
+	}
+	var p Principal
+	if err := json.Unmarshal(getResp.Kvs[0].Value, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Put issues token to p.
+func (s *EtcdStore) Put(ctx context.Context, token string, p Principal) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.cli.Put(ctx, key(HashToken(token)), string(data))
+	return err
+}
+
+// Delete revokes a token.
+func (s *EtcdStore) Delete(ctx context.Context, token string) error {
+	_, err := s.cli.Delete(ctx, key(HashToken(token)))
+	return err
+}
+
+// Close closes underlying etcd client.
+func (s *EtcdStore) Close() error {
+	if s.cli == nil {
+		return nil
+	}
+	return s.cli.Close()
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Name  string `json:"principal"`
+	Admin bool   `json:"admin,omitempty"`
+}
+
+// NormalizeName returns the form principal names are compared in: lower
+// case, like the [auth.user.<name>] sections of the config.
+func NormalizeName(name string) string {
+	return strings.ToLower(name)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the caller p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller of a request, or nil if the request was not
+// authenticated.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Name returns the name of ctx's caller, or "" if it is anonymous.
+func Name(ctx context.Context) string {
+	if p := FromContext(ctx); p != nil {
+		return p.Name
+	}
+	return ""
+}
+
+// HashToken returns the hex SHA-256 of a token, which is how tokens are
+// stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
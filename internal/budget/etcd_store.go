@@ -0,0 +1,140 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/qtopie/homa/internal/etcdkv"
+)
+
+// buckets is the number of buckets a window is split into; spending leaves
+// the window one bucket at a time.
+const buckets = 60
+
+// Spent is what was spent against a budget.
+type Spent struct {
+	Requests int64 `json:"requests"`
+	Tokens   int64 `json:"tokens"`
+}
+
+// EtcdStore keeps the spending of budgets over rolling windows in etcd. Each
+// budget and scope has a counter per slice of the window, which expires with
+// a lease once it has left the window.
+type EtcdStore struct {
+	cli *clientv3.Client
+}
+
+func NewEtcdStore(endpoints []string) (*EtcdStore, error) {
+	cli, err := etcdkv.Connect(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdStore{cli: cli}, nil
+}
+
+func prefix(budget, scope string) string {
+	return fmt.Sprintf("/budgets/%s/%s/", url.PathEscape(budget), url.PathEscape(scope))
+}
+
+// bucketWidth is the time covered by one counter of a window, in whole
+// seconds like the keys of the counters.
+func bucketWidth(window time.Duration) time.Duration {
+	width := (window / buckets).Truncate(time.Second)
+	if width < time.Second {
+		width = time.Second
+	}
+	return width
+}
+
+// bucketTTL is how long the bucket holding t stays in the window, in whole
+// seconds rounded up.
+func bucketTTL(window time.Duration, t time.Time) int64 {
+	width := bucketWidth(window)
+	left := window + width - t.Sub(t.Truncate(width))
+	return int64((left + time.Second - 1) / time.Second)
+}
+
+// inWindow reports whether the bucket starting at start, in unix seconds,
+// overlaps the window ending at t.
+func inWindow(start int64, window time.Duration, t time.Time) bool {
+	width := int64(bucketWidth(window) / time.Second)
+	return start+width > t.Add(-window).Unix()
+}
+
+// Add charges a request and its tokens at time t to the scope of a budget.
+func (s *EtcdStore) Add(ctx context.Context, budget, scope string, window time.Duration, t time.Time, tokens int64) error {
+	start := t.Truncate(bucketWidth(window))
+	key := prefix(budget, scope) + strconv.FormatInt(start.Unix(), 10)
+	lease, err := s.bucketLease(ctx, key, bucketTTL(window, t))
+	if err != nil {
+		return err
+	}
+	return etcdkv.Update(ctx, s.cli, key, func(value []byte) ([]byte, error) {
+		var spent Spent
+		if value != nil {
+			if err := json.Unmarshal(value, &spent); err != nil {
+				spent = Spent{}
+			}
+		}
+		spent.Requests++
+		spent.Tokens += tokens
+		return json.Marshal(spent)
+	}, clientv3.WithLease(lease))
+}
+
+// bucketLease returns the lease of the bucket at key, granting one that
+// expires once the bucket has left the window if the bucket is new.
+func (s *EtcdStore) bucketLease(ctx context.Context, key string, ttl int64) (clientv3.LeaseID, error) {
+	getResp, err := s.cli.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if len(getResp.Kvs) > 0 && getResp.Kvs[0].Lease != 0 {
+		return clientv3.LeaseID(getResp.Kvs[0].Lease), nil
+	}
+	leaseResp, err := s.cli.Grant(ctx, ttl)
+	if err != nil {
+		return 0, err
+	}
+	return leaseResp.ID, nil
+}
+
+// Spent sums what was spent against the scope of a budget in the window
+// ending at t.
+func (s *EtcdStore) Spent(ctx context.Context, budget, scope string, window time.Duration, t time.Time) (Spent, error) {
+	p := prefix(budget, scope)
+	getResp, err := s.cli.Get(ctx, p, clientv3.WithPrefix())
+	if err != nil {
+		return Spent{}, err
+	}
+
+	var total Spent
+	for _, kv := range getResp.Kvs {
+		start, err := strconv.ParseInt(strings.TrimPrefix(string(kv.Key), p), 10, 64)
+		if err != nil || !inWindow(start, window, t) {
+			continue
+		}
+		var spent Spent
+		if err := json.Unmarshal(kv.Value, &spent); err != nil {
+			continue
+		}
+		total.Requests += spent.Requests
+		total.Tokens += spent.Tokens
+	}
+	return total, nil
+}
+
+// Close closes underlying etcd client.
+func (s *EtcdStore) Close() error {
+	if s.cli == nil {
+		return nil
+	}
+	return s.cli.Close()
+}
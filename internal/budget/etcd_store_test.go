@@ -0,0 +1,68 @@
+package budget
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketWidth(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   time.Duration
+	}{
+		{time.Hour, time.Minute},
+		{24 * time.Hour, 24 * time.Minute},
+		{90 * time.Second, time.Second},
+		{10 * time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		if got := bucketWidth(tt.window); got != tt.want {
+			t.Errorf("bucketWidth(%s) = %s, want %s", tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestBucketTTL(t *testing.T) {
+	base := time.Unix(1_700_000_040, 0) // on a minute boundary
+	tests := []struct {
+		name   string
+		window time.Duration
+		t      time.Time
+		want   int64
+	}{
+		{"start of bucket", time.Hour, base, 3660},
+		{"middle of bucket", time.Hour, base.Add(30 * time.Second), 3630},
+		{"rounds up", time.Hour, base.Add(30*time.Second + time.Millisecond), 3630},
+		{"end of bucket", time.Hour, base.Add(59 * time.Second), 3601},
+		{"one second buckets", 10 * time.Second, base, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucketTTL(tt.window, tt.t); got != tt.want {
+				t.Errorf("bucketTTL() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	now := time.Unix(1_700_003_640, 0)
+	window := time.Hour
+	tests := []struct {
+		name  string
+		start int64
+		want  bool
+	}{
+		{"current bucket", now.Unix(), true},
+		{"bucket ending inside the window", now.Unix() - 3600, true},
+		{"bucket ending at the window's start", now.Unix() - 3660, false},
+		{"older bucket", now.Unix() - 7200, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inWindow(tt.start, window, now); got != tt.want {
+				t.Errorf("inWindow(%d) = %v, want %v", tt.start, got, tt.want)
+			}
+		})
+	}
+}
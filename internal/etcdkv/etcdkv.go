@@ -0,0 +1,58 @@
+// Package etcdkv holds the etcd plumbing shared by the stores of the server.
+package etcdkv
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// retryDelay is how long Update waits before reading a key again after
+// another writer changed it.
+const retryDelay = 10 * time.Millisecond
+
+// Connect creates a client for endpoints, or for a local etcd if none are
+// given.
+func Connect(endpoints []string) (*clientv3.Client, error) {
+	if len(endpoints) == 0 {
+		endpoints = []string{"localhost:2379"}
+	}
+	return clientv3.New(clientv3.Config{Endpoints: endpoints})
+}
+
+// Update reads key, passes its value to fn, nil if the key does not exist,
+// and writes back what fn returns with opts. If the key changed in between
+// it starts over, so fn may run more than once.
+func Update(ctx context.Context, cli *clientv3.Client, key string, fn func(value []byte) ([]byte, error), opts ...clientv3.OpOption) error {
+	for {
+		getResp, err := cli.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		var value []byte
+		cmp := clientv3.Compare(clientv3.Version(key), "=", 0)
+		if len(getResp.Kvs) > 0 {
+			value = getResp.Kvs[0].Value
+			cmp = clientv3.Compare(clientv3.ModRevision(key), "=", getResp.Kvs[0].ModRevision)
+		}
+		data, err := fn(value)
+		if err != nil {
+			return err
+		}
+
+		txnResp, err := cli.Txn(ctx).If(cmp).Then(clientv3.OpPut(key, string(data), opts...)).Commit()
+		if err != nil {
+			return err
+		}
+		if txnResp.Succeeded {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay):
+		}
+	}
+}
@@ -3,12 +3,15 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 
 	shared "github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/etcdkv"
 )
 
 type EtcdStore struct {
@@ -18,74 +21,175 @@ type EtcdStore struct {
 }
 
 func NewEtcdStore(endpoints []string, maxItems int, ttlSeconds int64) (*EtcdStore, error) {
-    if len(endpoints) == 0 {
-        endpoints = []string{"localhost:2379"}
-    }
-    cli, err := clientv3.New(clientv3.Config{Endpoints: endpoints})
+    cli, err := etcdkv.Connect(endpoints)
     if err != nil {
         return nil, err
     }
     return &EtcdStore{cli: cli, maxItems: maxItems, ttlSeconds: ttlSeconds}, nil
 }
 
+// ErrNotOwner is returned for a session bound to another principal or
+// workspace.
+var ErrNotOwner = errors.New("session belongs to another principal or workspace")
+
+// Info is who a session is bound to.
+type Info struct {
+    ID        string `json:"id"`
+    Owner     string `json:"owner"` // principal name, empty for anonymous callers
+    Workspace string `json:"workspace"`
+    Created   int64  `json:"created"` // unix seconds
+}
+
+// Session IDs are escaped in keys so that an ID cannot reach into the keys
+// of another session.
 func (s *EtcdStore) key(sessionID string) string {
-    return fmt.Sprintf("/sessions/%s/history", sessionID)
+    return fmt.Sprintf("/sessions/%s/history", url.PathEscape(sessionID))
 }
 
-// AppendHistory appends a message to the session history and trims to maxItems.
-func (s *EtcdStore) AppendHistory(ctx context.Context, sessionID string, msg shared.Message) error {
-    key := s.key(sessionID)
+func ownerKey(sessionID string) string {
+    return fmt.Sprintf("/sessions/%s/owner", url.PathEscape(sessionID))
+}
+
+// indexPrefix lists the sessions of an owner.
+func indexPrefix(owner string) string {
+    return fmt.Sprintf("/owners/%s/sessions/", url.PathEscape(owner))
+}
+
+// checkOwner returns ErrNotOwner unless a session bound as info may be used
+// by owner in workspace.
+func checkOwner(info Info, owner, workspace string) error {
+    if info.Owner != owner || info.Workspace != workspace {
+        return ErrNotOwner
+    }
+    return nil
+}
+
+// Bind binds a session to owner and workspace on first use, and afterwards
+// returns ErrNotOwner unless they match. Sessions with history from before
+// ownership belong to anonymous callers. The binding expires with the
+// history.
+func (s *EtcdStore) Bind(ctx context.Context, sessionID, owner, workspace string) error {
+    var putOpts []clientv3.OpOption
     for {
-        getResp, err := s.cli.Get(ctx, key)
+        getResp, err := s.cli.Get(ctx, ownerKey(sessionID))
         if err != nil {
             return err
         }
-
-        var hist []shared.Message
-        var modRev int64 = 0
         if len(getResp.Kvs) > 0 {
-            modRev = getResp.Kvs[0].ModRevision
-            if err := json.Unmarshal(getResp.Kvs[0].Value, &hist); err != nil {
-                hist = nil
+            var info Info
+            if err := json.Unmarshal(getResp.Kvs[0].Value, &info); err != nil {
+                return err
             }
+            return checkOwner(info, owner, workspace)
         }
 
-        hist = append(hist, msg)
-        if len(hist) > s.maxItems {
-            hist = hist[len(hist)-s.maxItems:]
-        }
-        data, err := json.Marshal(hist)
+        info := Info{ID: sessionID, Owner: owner, Workspace: workspace, Created: time.Now().Unix()}
+        data, err := json.Marshal(info)
         if err != nil {
             return err
         }
-
-        var putOpts []clientv3.OpOption
-        if s.ttlSeconds > 0 {
+        if s.ttlSeconds > 0 && putOpts == nil {
             leaseResp, err := s.cli.Grant(ctx, s.ttlSeconds)
             if err != nil {
                 return err
             }
             putOpts = append(putOpts, clientv3.WithLease(leaseResp.ID))
         }
-
-        var cmp clientv3.Cmp
-        if modRev == 0 {
-            cmp = clientv3.Compare(clientv3.Version(key), "=", 0)
-        } else {
-            cmp = clientv3.Compare(clientv3.ModRevision(key), "=", modRev)
-        }
-
-        txn := s.cli.Txn(ctx).If(cmp).Then(clientv3.OpPut(key, string(data), putOpts...))
-        txnResp, err := txn.Commit()
+        txnResp, err := s.cli.Txn(ctx).
+            If(
+                clientv3.Compare(clientv3.Version(ownerKey(sessionID)), "=", 0),
+                clientv3.Compare(clientv3.Version(s.key(sessionID)), "=", 0),
+            ).
+            Then(
+                clientv3.OpPut(ownerKey(sessionID), string(data), putOpts...),
+                clientv3.OpPut(indexPrefix(owner)+url.PathEscape(sessionID), string(data), putOpts...),
+            ).
+            Else(clientv3.OpGet(ownerKey(sessionID), clientv3.WithCountOnly())).
+            Commit()
         if err != nil {
             return err
         }
         if txnResp.Succeeded {
             return nil
         }
-        // compare failed, retry
-        time.Sleep(10 * time.Millisecond)
+        if txnResp.Responses[0].GetResponseRange().Count == 0 {
+            // History without an owner predates ownership
+            if owner != "" {
+                return ErrNotOwner
+            }
+            return nil
+        }
+        // bound concurrently, check again
+    }
+}
+
+// lease returns the lease a session's keys expire with, renewed so that the
+// session lives on for ttlSeconds. Sessions bound before they had a lease get
+// a new one.
+func (s *EtcdStore) lease(ctx context.Context, sessionID string) (clientv3.LeaseID, error) {
+    getResp, err := s.cli.Get(ctx, ownerKey(sessionID))
+    if err != nil {
+        return 0, err
+    }
+    if len(getResp.Kvs) > 0 && getResp.Kvs[0].Lease != 0 {
+        id := clientv3.LeaseID(getResp.Kvs[0].Lease)
+        if _, err := s.cli.KeepAliveOnce(ctx, id); err != nil {
+            return 0, err
+        }
+        return id, nil
+    }
+    leaseResp, err := s.cli.Grant(ctx, s.ttlSeconds)
+    if err != nil {
+        return 0, err
+    }
+    return leaseResp.ID, nil
+}
+
+// List returns the sessions bound to owner, or to anyone if all is set.
+func (s *EtcdStore) List(ctx context.Context, owner string, all bool) ([]Info, error) {
+    prefix := indexPrefix(owner)
+    if all {
+        prefix = "/owners/"
+    }
+    getResp, err := s.cli.Get(ctx, prefix, clientv3.WithPrefix())
+    if err != nil {
+        return nil, err
+    }
+    sessions := make([]Info, 0, len(getResp.Kvs))
+    for _, kv := range getResp.Kvs {
+        var info Info
+        if err := json.Unmarshal(kv.Value, &info); err != nil {
+            continue
+        }
+        sessions = append(sessions, info)
     }
+    return sessions, nil
+}
+
+// AppendHistory appends a message to the session history and trims to maxItems.
+func (s *EtcdStore) AppendHistory(ctx context.Context, sessionID string, msg shared.Message) error {
+    var putOpts []clientv3.OpOption
+    if s.ttlSeconds > 0 {
+        lease, err := s.lease(ctx, sessionID)
+        if err != nil {
+            return err
+        }
+        putOpts = append(putOpts, clientv3.WithLease(lease))
+    }
+
+    return etcdkv.Update(ctx, s.cli, s.key(sessionID), func(value []byte) ([]byte, error) {
+        var hist []shared.Message
+        if value != nil {
+            if err := json.Unmarshal(value, &hist); err != nil {
+                hist = nil
+            }
+        }
+        hist = append(hist, msg)
+        if len(hist) > s.maxItems {
+            hist = hist[len(hist)-s.maxItems:]
+        }
+        return json.Marshal(hist)
+    }, putOpts...)
 }
 
 // GetHistory returns up to maxItems recent messages for a session.
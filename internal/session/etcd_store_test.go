@@ -0,0 +1,56 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckOwner(t *testing.T) {
+	bound := Info{ID: "s1", Owner: "alice", Workspace: "/work/app"}
+	anonymous := Info{ID: "s2", Workspace: "/work/app"}
+
+	tests := []struct {
+		name      string
+		info      Info
+		owner     string
+		workspace string
+		wantErr   bool
+	}{
+		{"owner in its workspace", bound, "alice", "/work/app", false},
+		{"another principal", bound, "bob", "/work/app", true},
+		{"anonymous caller", bound, "", "/work/app", true},
+		{"another workspace", bound, "alice", "/work/other", true},
+		{"no workspace", bound, "alice", "", true},
+		{"anonymous session", anonymous, "", "/work/app", false},
+		{"anonymous session by a principal", anonymous, "alice", "/work/app", true},
+		{"session without a workspace", Info{Owner: "alice"}, "alice", "", false},
+		{"session without a workspace used in one", Info{Owner: "alice"}, "alice", "/work/app", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOwner(tt.info, tt.owner, tt.workspace)
+			if tt.wantErr != errors.Is(err, ErrNotOwner) {
+				t.Errorf("checkOwner() = %v, want ErrNotOwner: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeysEscapeSessionIDs(t *testing.T) {
+	s := &EtcdStore{}
+	tests := []struct {
+		id, history, owner string
+	}{
+		{"s1", "/sessions/s1/history", "/sessions/s1/owner"},
+		{"a/b", "/sessions/a%2Fb/history", "/sessions/a%2Fb/owner"},
+		{"../s1/owner", "/sessions/..%2Fs1%2Fowner/history", "/sessions/..%2Fs1%2Fowner/owner"},
+	}
+	for _, tt := range tests {
+		if got := s.key(tt.id); got != tt.history {
+			t.Errorf("key(%q) = %q, want %q", tt.id, got, tt.history)
+		}
+		if got := ownerKey(tt.id); got != tt.owner {
+			t.Errorf("ownerKey(%q) = %q, want %q", tt.id, got, tt.owner)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	shared "github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/etcdkv"
+)
+
+// DayLayout is the format of the days usage is accounted by, in UTC.
+const DayLayout = "2006-01-02"
+
+const prefix = "/usage/"
+
+// Counters are the usage accounted to one key.
+type Counters struct {
+	Requests         int64 `json:"requests"`
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	CachedTokens     int64 `json:"cachedTokens"`
+}
+
+// Add adds the counts of o to c.
+func (c *Counters) Add(o Counters) {
+	c.Requests += o.Requests
+	c.PromptTokens += o.PromptTokens
+	c.CompletionTokens += o.CompletionTokens
+	c.CachedTokens += o.CachedTokens
+}
+
+// Record is the usage of one plugin in one session and workspace on one day.
+type Record struct {
+	Day       string
+	Workspace string
+	SessionID string
+	Plugin    string
+	Counters
+}
+
+// EtcdStore keeps usage counters in etcd, one key per day, workspace,
+// session and plugin.
+type EtcdStore struct {
+	cli *clientv3.Client
+}
+
+func NewEtcdStore(endpoints []string) (*EtcdStore, error) {
+	cli, err := etcdkv.Connect(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdStore{cli: cli}, nil
+}
+
+func key(day, workspace, sessionID, plugin string) string {
+	return fmt.Sprintf("%s%s/%s/%s/%s", prefix, day,
+		url.PathEscape(workspace), url.PathEscape(sessionID), url.PathEscape(plugin))
+}
+
+// parseKey is the inverse of key.
+func parseKey(k string) (Record, bool) {
+	parts := strings.Split(strings.TrimPrefix(k, prefix), "/")
+	if len(parts) != 4 {
+		return Record{}, false
+	}
+	var err error
+	r := Record{Day: parts[0]}
+	for i, dst := range []*string{&r.Workspace, &r.SessionID, &r.Plugin} {
+		if *dst, err = url.PathUnescape(parts[i+1]); err != nil {
+			return Record{}, false
+		}
+	}
+	return r, true
+}
+
+// Add accounts one request and its token usage at time t.
+func (s *EtcdStore) Add(ctx context.Context, t time.Time, workspace, sessionID, plugin string, u shared.Usage) error {
+	k := key(t.UTC().Format(DayLayout), workspace, sessionID, plugin)
+	delta := Counters{
+		Requests:         1,
+		PromptTokens:     int64(u.PromptTokens),
+		CompletionTokens: int64(u.CompletionTokens),
+		CachedTokens:     int64(u.CachedTokens),
+	}
+	return etcdkv.Update(ctx, s.cli, k, func(value []byte) ([]byte, error) {
+		var c Counters
+		if value != nil {
+			if err := json.Unmarshal(value, &c); err != nil {
+				c = Counters{}
+			}
+		}
+		c.Add(delta)
+		return json.Marshal(c)
+	})
+}
+
+// List returns the records of the days from to to, both included and given
+// in DayLayout.
+func (s *EtcdStore) List(ctx context.Context, from, to string) ([]Record, error) {
+	end := clientv3.GetPrefixRangeEnd(prefix + to + "/")
+	getResp, err := s.cli.Get(ctx, prefix+from+"/", clientv3.WithRange(end))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(getResp.Kvs))
+	for _, kv := range getResp.Kvs {
+		r, ok := parseKey(string(kv.Key))
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(kv.Value, &r.Counters); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// Close closes underlying etcd client.
+func (s *EtcdStore) Close() error {
+	if s.cli == nil {
+		return nil
+	}
+	return s.cli.Close()
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net"
+	"os"
 
 	"github.com/qtopie/homa/gen/assistant"
 	cfg "github.com/qtopie/homa/internal/app/config"
@@ -11,14 +13,42 @@ import (
 	"google.golang.org/grpc/reflection"
 )
 
+var listPlugins = flag.Bool("list-plugins", false, "print the plugin catalogue and exit")
+
 func main() {
-	go startEtcd()
+	flag.Parse()
+	if err := cfg.Load(); err != nil {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+
 	// Initialize the PluginManager
 	pluginManager := NewPluginManager("/opt/homa/plugins")
+	if *listPlugins {
+		catalogue, err := pluginManager.Catalogue()
+		if err != nil {
+			log.Fatalf("Failed to scan plugins directory: %v", err)
+		}
+		if err := PrintCatalogue(os.Stdout, catalogue); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	logCatalogue(pluginManager)
+
+	go startEtcd()
 
 	// Create the CopilotServiceServerImpl
 	copilotService := NewCopilotServiceServerImpl(pluginManager)
 
+	// Pick up plugin switches and updated plugin binaries without a restart
+	cfg.OnChange(copilotService.OnConfigChange)
+	cfg.WatchConfig()
+	if stop, err := pluginManager.WatchPlugins(copilotService.OnPluginFileChange); err != nil {
+		log.Printf("Plugin hot reload disabled: %v", err)
+	} else {
+		defer stop()
+	}
+
 	// Start the gRPC server
 	address := cfg.GetAppConfig().GetString("app.address")
 	lis, err := net.Listen("tcp", address)
@@ -26,8 +56,25 @@ func main() {
 		log.Fatalf("Failed to listen on %s: %v", address, err)
 	}
 
-	grpcServer := grpc.NewServer()
+	authn := newAuthenticator()
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(recoveryUnaryInterceptor, authn.unaryInterceptor),
+		grpc.ChainStreamInterceptor(recoveryStreamInterceptor, authn.streamInterceptor),
+	}
+	creds, err := serverCredentials()
+	if err != nil {
+		log.Fatalf("Failed to set up TLS: %v", err)
+	}
+	if creds != nil {
+		opts = append(opts, creds)
+	} else if authEnabled() {
+		log.Printf("Authentication is enabled without TLS; tokens are sent in the clear")
+	}
+	grpcServer := grpc.NewServer(opts...)
 	assistant.RegisterCopilotServiceServer(grpcServer, copilotService)
+	assistant.RegisterPluginAdminServiceServer(grpcServer, NewAdminServiceServerImpl(pluginManager, copilotService))
+	assistant.RegisterUsageServiceServer(grpcServer, NewUsageServiceServerImpl(copilotService))
+	assistant.RegisterSessionAdminServiceServer(grpcServer, NewSessionAdminServiceServerImpl(copilotService))
 	reflection.Register(grpcServer)
 
 	fmt.Println("Starting process on", address)
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+)
+
+// TestMain loads an empty config file; tests set the keys they need with
+// setConfig.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "homa-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	file := filepath.Join(dir, "config.ini")
+	err = os.WriteFile(file, []byte("[app]\naddress = localhost:0\n"), 0o600)
+	if err == nil {
+		err = cfg.LoadFile(file)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+// Catalogue states of a plugin
+const (
+	PluginAvailable = "available" // found on disk, not loaded
+	PluginLoaded    = "loaded"
+	PluginBroken    = "broken" // bad manifest, missing config or failed to load
+)
+
+// PluginManifest describes a plugin. It is read from <name>.json next to the
+// plugin file; a plugin without one is still usable.
+type PluginManifest struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Category     string            `json:"category"`
+	Description  string            `json:"description,omitempty"`
+	Capabilities []string          `json:"capabilities,omitempty"` // e.g. "chat", "autocomplete"
+	Config       []PluginConfigKey `json:"config,omitempty"`
+}
+
+// PluginConfigKey documents a key of the plugin's [plugins.<name>] section.
+type PluginConfigKey struct {
+	Key         string `json:"key"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PluginInfo is a catalogue entry for a plugin found in the plugins directory
+// or known to the PluginManager.
+type PluginInfo struct {
+	Category string
+	Name     string
+	Mode     string
+	Path     string
+	Manifest *PluginManifest // nil if the plugin has no manifest
+	Status   string
+	Error    string // why the plugin is broken
+}
+
+// Catalogue scans the plugins directory and reports every plugin found there
+// together with the loaded and failed ones, sorted by category and name.
+func (pm *PluginManager) Catalogue() ([]PluginInfo, error) {
+	found := make(map[[2]string]*PluginInfo)
+
+	categories, err := os.ReadDir(pm.basePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, dir := range categories {
+		if !dir.IsDir() {
+			continue
+		}
+		category := dir.Name()
+		entries, err := os.ReadDir(filepath.Join(pm.basePath, category))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			pluginName, ok := pluginFileName(entry)
+			if !ok {
+				continue
+			}
+			key := [2]string{category, pluginName}
+			if found[key] == nil {
+				found[key] = pm.inspectPlugin(category, pluginName)
+			}
+		}
+	}
+
+	// Loaded plugins whose file has since been removed are still serving
+	pm.mu.Lock()
+	for category, plugins := range pm.plugins {
+		for pluginName, loaded := range plugins {
+			key := [2]string{category, pluginName}
+			if found[key] == nil {
+				found[key] = &PluginInfo{Category: category, Name: pluginName, Mode: loaded.Mode, Path: loaded.Path}
+			}
+			found[key].Status, found[key].Error = PluginLoaded, ""
+		}
+	}
+	for category, failures := range pm.failures {
+		for pluginName, err := range failures {
+			key := [2]string{category, pluginName}
+			if found[key] == nil {
+				found[key] = &PluginInfo{Category: category, Name: pluginName}
+			}
+			if found[key].Status != PluginLoaded {
+				found[key].Status, found[key].Error = PluginBroken, err.Error()
+			}
+		}
+	}
+	pm.mu.Unlock()
+
+	catalogue := make([]PluginInfo, 0, len(found))
+	for _, info := range found {
+		catalogue = append(catalogue, *info)
+	}
+	sort.Slice(catalogue, func(i, j int) bool {
+		if catalogue[i].Category != catalogue[j].Category {
+			return catalogue[i].Category < catalogue[j].Category
+		}
+		return catalogue[i].Name < catalogue[j].Name
+	})
+	return catalogue, nil
+}
+
+// pluginFileName returns the plugin name for a .so, manifest or executable
+// file in a category directory.
+func pluginFileName(entry os.DirEntry) (string, bool) {
+	name := entry.Name()
+	if entry.IsDir() || strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	switch filepath.Ext(name) {
+	case ".so", ".json":
+		return strings.TrimSuffix(name, filepath.Ext(name)), true
+	case "":
+		info, err := entry.Info()
+		return name, err == nil && info.Mode()&0o111 != 0
+	}
+	return "", false
+}
+
+// inspectPlugin builds the catalogue entry for a plugin on disk without
+// loading it.
+func (pm *PluginManager) inspectPlugin(category, pluginName string) *PluginInfo {
+	info := &PluginInfo{Category: category, Name: pluginName, Status: PluginAvailable}
+
+	config := pluginConfig(pluginName)
+	manifest, err := pm.readManifest(category, pluginName)
+	info.Manifest = manifest
+	if err == nil {
+		err = checkManifest(category, pluginName, manifest, config)
+	}
+	if err == nil {
+		info.Mode, info.Path, err = pm.resolvePlugin(category, pluginName, config.GetString("mode"))
+	}
+	if err == nil {
+		if _, statErr := os.Stat(info.Path); statErr != nil {
+			err = fmt.Errorf("plugin %s: %w", pluginName, statErr)
+		}
+	}
+	if err != nil {
+		info.Status, info.Error = PluginBroken, err.Error()
+	}
+	return info
+}
+
+// readManifest reads a plugin's manifest. A missing manifest is not an error.
+func (pm *PluginManager) readManifest(category, pluginName string) (*PluginManifest, error) {
+	data, err := os.ReadFile(filepath.Join(pm.basePath, category, pluginName+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest for plugin %s: %w", pluginName, err)
+	}
+	return &manifest, nil
+}
+
+// checkManifest verifies that a manifest matches where the plugin is installed
+// and that the config keys it requires are set.
+func checkManifest(category, pluginName string, manifest *PluginManifest, config shared.PluginConfig) error {
+	if manifest == nil {
+		return nil
+	}
+	if manifest.Name != "" && manifest.Name != pluginName {
+		return fmt.Errorf("manifest of plugin %s names it %q", pluginName, manifest.Name)
+	}
+	if manifest.Category != "" && manifest.Category != category {
+		return fmt.Errorf("plugin %s is installed under %s but its manifest says %s", pluginName, category, manifest.Category)
+	}
+
+	var missing []string
+	for _, key := range manifest.Config {
+		if key.Required && config.GetString(key.Key) == "" {
+			missing = append(missing, key.Key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("plugin %s is missing required config in [plugins.%s]: %s", pluginName, pluginName, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// PrintCatalogue writes the plugin catalogue as a table.
+func PrintCatalogue(w io.Writer, catalogue []PluginInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tNAME\tVERSION\tMODE\tSTATUS\tCAPABILITIES\tERROR")
+	for _, info := range catalogue {
+		version, capabilities := "-", "-"
+		if info.Manifest != nil {
+			if info.Manifest.Version != "" {
+				version = info.Manifest.Version
+			}
+			if len(info.Manifest.Capabilities) > 0 {
+				capabilities = strings.Join(info.Manifest.Capabilities, ",")
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.Category, info.Name, version, orDash(info.Mode), info.Status, capabilities, orDash(info.Error))
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// logCatalogue reports the plugins found at startup, so broken ones show up
+// before anyone switches to them.
+func logCatalogue(pm *PluginManager) {
+	catalogue, err := pm.Catalogue()
+	if err != nil {
+		log.Printf("Failed to scan plugins directory: %v", err)
+		return
+	}
+	for _, info := range catalogue {
+		if info.Status == PluginBroken {
+			log.Printf("Plugin %s/%s is broken: %s", info.Category, info.Name, info.Error)
+		} else {
+			log.Printf("Plugin %s/%s is %s", info.Category, info.Name, info.Status)
+		}
+	}
+}
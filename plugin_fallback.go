@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/qtopie/homa/gen/assistant"
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pluginHeader is the response header naming the plugin that answered.
+const pluginHeader = "x-homa-plugin"
+
+// fallbackPlugins returns the ordered fallback chain for an RPC, read from the
+// comma-separated chat and autocomplete keys of the [fallback] section.
+func fallbackPlugins(rpc string) []string {
+	return pluginList(cfg.GetAppConfig().GetString("fallback." + rpc))
+}
+
+// pluginList splits a comma-separated list of plugin names.
+func pluginList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// candidatePlugins lists the plugins to try for a request: the routed or
+// active plugin, then its fallbacks. A request pinned by a strict route never
+// falls back.
+func (s *CopilotServiceServerImpl) candidatePlugins(rpc string, req *assistant.UserRequest) []string {
+	name, strict := routePlugin(rpc, req)
+	if name == "" {
+		name = s.selectedPlugin()
+	}
+	if strict {
+		return []string{name}
+	}
+
+	candidates := []string{name}
+	for _, fallback := range fallbackPlugins(rpc) {
+		duplicate := false
+		for _, c := range candidates {
+			duplicate = duplicate || c == fallback
+		}
+		if !duplicate {
+			candidates = append(candidates, fallback)
+		}
+	}
+	return candidates
+}
+
+// retryable reports whether a failed plugin call may be retried on the next
+// plugin: quota exhausted, upstream unavailable or timed out, as long as the
+// client itself has not gone away.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/qtopie/homa/gen/assistant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"rate limited", context.Background(), status.Error(codes.ResourceExhausted, "quota"), true},
+		{"unavailable", context.Background(), status.Error(codes.Unavailable, "down"), true},
+		{"deadline exceeded", context.Background(), status.Error(codes.DeadlineExceeded, "slow"), true},
+		{"invalid argument", context.Background(), status.Error(codes.InvalidArgument, "bad"), false},
+		{"internal", context.Background(), status.Error(codes.Internal, "bug"), false},
+		{"plain error", context.Background(), errors.New("boom"), false},
+		{"client gone", canceled, status.Error(codes.Unavailable, "down"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidatePlugins(t *testing.T) {
+	setConfig(t, "plugins.copilot", "gemini")
+	setConfig(t, "fallback", map[string]any{"chat": "eino, gemini, local"})
+	setConfig(t, "route", map[string]any{
+		"hints":     "local",
+		"10-secret": map[string]any{"workspace": "/work/secret", "plugin": "local"},
+	})
+	s := &CopilotServiceServerImpl{}
+
+	tests := []struct {
+		name string
+		req  *assistant.UserRequest
+		want []string
+	}{
+		{"active plugin then fallbacks", &assistant.UserRequest{}, []string{"gemini", "eino", "local"}},
+		{"hinted plugin first", &assistant.UserRequest{Plugin: "local"}, []string{"local", "eino", "gemini"}},
+		{"hint not allowed", &assistant.UserRequest{Plugin: "mock"}, []string{"gemini", "eino", "local"}},
+		{"strict route never falls back", &assistant.UserRequest{Workspace: "/work/secret"}, []string{"local"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.candidatePlugins(rpcChat, tt.req); !slices.Equal(got, tt.want) {
+				t.Errorf("candidatePlugins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/qtopie/homa/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// clientHeader is the request header a client may name itself with, for
+// fair scheduling among the clients sharing an address.
+const clientHeader = "x-homa-client"
+
+// defaultQueueTimeout bounds how long a request waits for a busy plugin when
+// its section sets no queue-timeout.
+const defaultQueueTimeout = 10 * time.Second
+
+// clientIdentity names the client a request comes from: the authenticated
+// principal, else the peer's address. The x-homa-client header only tells
+// apart the clients behind one address, so that a client cannot take the
+// turns of another address by naming it.
+func clientIdentity(ctx context.Context) string {
+	if name := auth.Name(ctx); name != "" {
+		return name
+	}
+	var host string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host = p.Addr.String()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(clientHeader); len(v) > 0 && v[0] != "" {
+			return host + "/" + v[0]
+		}
+	}
+	return host
+}
+
+// limiterConfig is the limits set in a plugin's config section.
+type limiterConfig struct {
+	maxConcurrency int           // calls running at once, 0 means unlimited
+	rate           float64       // calls started per second, 0 means unlimited
+	burst          int           // calls started at once after a pause
+	queueTimeout   time.Duration // longest wait for a turn
+}
+
+func loadLimiterConfig(name string) limiterConfig {
+	conf := pluginConfig(name)
+	c := limiterConfig{
+		maxConcurrency: conf.GetInt("max-concurrency", 0),
+		rate:           conf.GetFloat("rate", 0),
+		burst:          conf.GetInt("burst", 1),
+		queueTimeout:   conf.GetDuration("queue-timeout", defaultQueueTimeout),
+	}
+	if c.burst < 1 {
+		c.burst = 1
+	}
+	return c
+}
+
+// pluginLimits holds a limiter per plugin.
+type pluginLimits struct {
+	mu       sync.Mutex
+	limiters map[string]*pluginLimiter
+}
+
+func newPluginLimits() *pluginLimits {
+	return &pluginLimits{limiters: make(map[string]*pluginLimiter)}
+}
+
+// acquire waits for client's turn to call the plugin and returns the
+// function ending the call. A request that cannot get a turn before its
+// deadline or the plugin's queue timeout fails with ResourceExhausted, so it
+// may fall back to another plugin.
+func (l *pluginLimits) acquire(ctx context.Context, name, client string) (func(), error) {
+	conf := loadLimiterConfig(name)
+	l.mu.Lock()
+	limiter := l.limiters[name]
+	if limiter == nil {
+		if conf.maxConcurrency <= 0 && conf.rate <= 0 {
+			l.mu.Unlock()
+			return func() {}, nil
+		}
+		limiter = newPluginLimiter()
+		l.limiters[name] = limiter
+	}
+	l.mu.Unlock()
+
+	limiter.configure(conf)
+	return limiter.acquire(ctx, name, client, conf.queueTimeout)
+}
+
+// pluginLimiter bounds the calls to one plugin by concurrency and by a token
+// bucket. Calls that have to wait are queued per client and the clients are
+// served in turn, so one client's burst only delays that client.
+type pluginLimiter struct {
+	mu   sync.Mutex
+	conf limiterConfig
+
+	running int
+	tokens  float64
+	refill  time.Time
+
+	queues map[string][]*limiterWaiter
+	order  []string // clients with waiting calls, the next to serve first
+	timer  *time.Timer
+}
+
+type limiterWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+func newPluginLimiter() *pluginLimiter {
+	return &pluginLimiter{queues: make(map[string][]*limiterWaiter)}
+}
+
+// configure applies the plugin's current limits; a raised limit lets queued
+// calls run.
+func (p *pluginLimiter) configure(conf limiterConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conf == conf {
+		return
+	}
+	if p.refill.IsZero() || conf.burst != p.conf.burst {
+		p.tokens = float64(conf.burst)
+		p.refill = time.Now()
+	}
+	p.conf = conf
+	p.dispatch()
+}
+
+func (p *pluginLimiter) acquire(ctx context.Context, name, client string, timeout time.Duration) (func(), error) {
+	p.mu.Lock()
+	if len(p.order) == 0 && p.available() {
+		p.take()
+		p.mu.Unlock()
+		return p.release, nil
+	}
+	w := &limiterWaiter{ready: make(chan struct{})}
+	if len(p.queues[client]) == 0 {
+		p.order = append(p.order, client)
+	}
+	p.queues[client] = append(p.queues[client], w)
+	// Arms the timer when only the rate holds the call back
+	p.dispatch()
+	p.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.ready:
+		return p.release, nil
+	case <-ctx.Done():
+		err = status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		err = status.Errorf(codes.ResourceExhausted, "copilot plugin %s is busy: no turn within %s", name, timeout)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if w.granted {
+		// The turn came as the wait ended; hand it on
+		p.untake()
+		p.dispatch()
+		return nil, err
+	}
+	p.remove(client, w)
+	return nil, err
+}
+
+func (p *pluginLimiter) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+	p.dispatch()
+}
+
+// available reports whether a call may start now, refilling the bucket.
+func (p *pluginLimiter) available() bool {
+	if p.conf.maxConcurrency > 0 && p.running >= p.conf.maxConcurrency {
+		return false
+	}
+	if p.conf.rate <= 0 {
+		return true
+	}
+	now := time.Now()
+	p.tokens += now.Sub(p.refill).Seconds() * p.conf.rate
+	if max := float64(p.conf.burst); p.tokens > max {
+		p.tokens = max
+	}
+	p.refill = now
+	return p.tokens >= 1
+}
+
+func (p *pluginLimiter) take() {
+	p.running++
+	if p.conf.rate > 0 {
+		p.tokens--
+	}
+}
+
+// untake gives back a turn that was granted but not used, token included.
+func (p *pluginLimiter) untake() {
+	p.running--
+	if p.conf.rate > 0 {
+		p.tokens = min(p.tokens+1, float64(p.conf.burst))
+	}
+}
+
+// dispatch starts queued calls while the limits allow, one per client in
+// turn. When only the rate holds them back, it runs again once the next
+// token is due.
+func (p *pluginLimiter) dispatch() {
+	for len(p.order) > 0 && p.available() {
+		client := p.order[0]
+		queue := p.queues[client]
+		w := queue[0]
+		p.order = p.order[1:]
+		if len(queue) > 1 {
+			p.queues[client] = queue[1:]
+			p.order = append(p.order, client)
+		} else {
+			delete(p.queues, client)
+		}
+
+		p.take()
+		w.granted = true
+		close(w.ready)
+	}
+
+	if len(p.order) == 0 || p.timer != nil || p.conf.rate <= 0 || p.tokens >= 1 {
+		return
+	}
+	wait := time.Duration((1 - p.tokens) / p.conf.rate * float64(time.Second))
+	p.timer = time.AfterFunc(wait, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.timer = nil
+		p.dispatch()
+	})
+}
+
+// remove drops a waiter that gave up.
+func (p *pluginLimiter) remove(client string, w *limiterWaiter) {
+	queue := p.queues[client]
+	for i, q := range queue {
+		if q == w {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		p.queues[client] = queue
+		return
+	}
+	delete(p.queues, client)
+	for i, c := range p.order {
+		if c == client {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// queued waits until client has n calls waiting in p.
+func queued(t *testing.T, p *pluginLimiter, client string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		p.mu.Lock()
+		got := len(p.queues[client])
+		p.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d calls queued, want %d", client, got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPluginLimiterTakesTurnsPerClient(t *testing.T) {
+	p := newPluginLimiter()
+	p.configure(limiterConfig{maxConcurrency: 1, burst: 1})
+	release, err := p.acquire(context.Background(), "gemini", "alice", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	granted := make(chan string)
+	enqueue := func(client string, n int) {
+		go func() {
+			done, err := p.acquire(context.Background(), "gemini", client, time.Second)
+			if err != nil {
+				t.Error(err)
+				granted <- ""
+				return
+			}
+			granted <- client
+			done()
+		}()
+		queued(t, p, client, n)
+	}
+	// alice queues a burst before bob's single call
+	enqueue("alice", 1)
+	enqueue("alice", 2)
+	enqueue("alice", 3)
+	enqueue("bob", 1)
+
+	release()
+	var order []string
+	for range 4 {
+		order = append(order, <-granted)
+	}
+	if want := []string{"alice", "bob", "alice", "alice"}; !slices.Equal(order, want) {
+		t.Errorf("served %v, want %v", order, want)
+	}
+}
+
+func TestPluginLimiterTimeout(t *testing.T) {
+	p := newPluginLimiter()
+	p.configure(limiterConfig{maxConcurrency: 1, burst: 1})
+	release, err := p.acquire(context.Background(), "gemini", "alice", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	_, err = p.acquire(context.Background(), "gemini", "bob", 10*time.Millisecond)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("err = %v, want ResourceExhausted", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queues) != 0 || len(p.order) != 0 {
+		t.Errorf("timed out call still queued: %v %v", p.queues, p.order)
+	}
+}
+
+func TestPluginLimiterHandsOnTurnGrantedAtTimeout(t *testing.T) {
+	p := newPluginLimiter()
+	p.configure(limiterConfig{maxConcurrency: 1, burst: 1})
+	if _, err := p.acquire(context.Background(), "gemini", "alice", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	bobErr := make(chan error)
+	go func() {
+		_, err := p.acquire(context.Background(), "gemini", "bob", 100*time.Millisecond)
+		bobErr <- err
+	}()
+	queued(t, p, "bob", 1)
+	carolDone := make(chan func())
+	go func() {
+		done, err := p.acquire(context.Background(), "gemini", "carol", time.Second)
+		if err != nil {
+			t.Error(err)
+			done = func() {}
+		}
+		carolDone <- done
+	}()
+	queued(t, p, "carol", 1)
+
+	// bob's wait times out while alice's turn ends and is granted to him
+	p.mu.Lock()
+	time.Sleep(200 * time.Millisecond)
+	p.running--
+	p.dispatch()
+	p.mu.Unlock()
+
+	if err := <-bobErr; status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("bob's err = %v, want ResourceExhausted", err)
+	}
+	select {
+	case done := <-carolDone:
+		done()
+	case <-time.After(time.Second):
+		t.Fatal("bob's turn was not handed on to carol")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running != 0 {
+		t.Errorf("running = %d after every call ended", p.running)
+	}
+}
+
+func TestPluginLimiterRefundsTokenGrantedAtTimeout(t *testing.T) {
+	p := newPluginLimiter()
+	// One token, refilled far too slowly to matter during the test
+	p.configure(limiterConfig{rate: 0.001, burst: 1})
+	done, err := p.acquire(context.Background(), "gemini", "alice", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+
+	bobErr := make(chan error)
+	go func() {
+		_, err := p.acquire(context.Background(), "gemini", "bob", 100*time.Millisecond)
+		bobErr <- err
+	}()
+	queued(t, p, "bob", 1)
+
+	// bob's wait times out while a token comes due and is granted to him
+	p.mu.Lock()
+	time.Sleep(200 * time.Millisecond)
+	p.tokens++
+	p.dispatch()
+	p.mu.Unlock()
+
+	if err := <-bobErr; status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("bob's err = %v, want ResourceExhausted", err)
+	}
+	// The token bob did not use is still there for the next call
+	done, err = p.acquire(context.Background(), "gemini", "carol", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("token was not refunded: %v", err)
+	}
+	done()
+}
@@ -1,64 +1,383 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"plugin"
 	"sync"
+	"time"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/rpcplugin"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
 )
 
 const (
 	APP_DATA_DIR = "/opt/homa"
 )
 
+// Plugin backends, selected with the "mode" key of a plugin's config section.
+// Without it, a <name>.so file is preferred over a <name> executable.
+const (
+	ModeInProcess = "inprocess" // Go plugin (.so) opened into the server
+	ModeProcess   = "process"   // executable supervised as a child process
+)
+
+// LoadedPlugin is a plugin registered with the PluginManager
+type LoadedPlugin struct {
+	Category  string
+	Name      string
+	Mode      string
+	Path      string
+	Hash      string                 // sha256 of the plugin file this instance was opened from
+	Instance  interface{}            // the symbol exported as "Plugin"
+	Lifecycle shared.PluginLifecycle // nil if the plugin has no lifecycle hooks
+	Manifest  *PluginManifest        // nil if the plugin has no manifest
+	LoadedAt  time.Time
+}
+
+// Health probes the plugin's backend. Plugins without lifecycle hooks are
+// assumed healthy.
+func (l *LoadedPlugin) Health(ctx context.Context) (err error) {
+	if l.Lifecycle == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin %s panicked during health check: %v", l.Name, r)
+		}
+	}()
+	return l.Lifecycle.Health(ctx)
+}
+
 // PluginManager manages dynamically loaded plugins
 type PluginManager struct {
 	mu       sync.Mutex
-	plugins  map[string]map[string]interface{} // category -> plugin name -> plugin instance
-	basePath string                            // Base directory for plugins
+	plugins  map[string]map[string]*LoadedPlugin // category -> plugin name -> loaded plugin
+	failures map[string]map[string]error         // category -> plugin name -> last load error
+	basePath string                              // Base directory for plugins
+	cacheDir string                              // Versioned copies of opened plugin files
 }
 
 // NewPluginManager creates a new PluginManager
 func NewPluginManager(basePath string) *PluginManager {
 	return &PluginManager{
-		plugins:  make(map[string]map[string]interface{}),
+		plugins:  make(map[string]map[string]*LoadedPlugin),
+		failures: make(map[string]map[string]error),
 		basePath: basePath,
+		cacheDir: filepath.Join(APP_DATA_DIR, "cache", "plugins"),
 	}
 }
 
-// LoadPlugin dynamically loads a plugin by category and name
+// LoadPlugin dynamically loads a plugin by category and name and initializes
+// it with its [plugins.<name>] config section. Loading a plugin that is
+// already registered is a no-op. A plugin that fails to load or initialize is
+// recorded as failed and not registered.
 func (pm *PluginManager) LoadPlugin(category, pluginName string) error {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	if _, exists := pm.getLoaded(category, pluginName); exists {
+		return nil
+	}
 
-	// Ensure the category map exists
-	if _, exists := pm.plugins[category]; !exists {
-		pm.plugins[category] = make(map[string]interface{})
+	loaded, fresh, err := pm.StagePlugin(category, pluginName)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		pm.Register(loaded)
+	}
+	return nil
+}
+
+// StagePlugin opens and initializes the current version of a plugin file
+// without registering it, so the caller can health check it before switching
+// traffic. If that exact version is already registered, the registered plugin
+// is returned with fresh set to false.
+func (pm *PluginManager) StagePlugin(category, pluginName string) (loaded *LoadedPlugin, fresh bool, err error) {
+	if !validPluginName(category) || !validPluginName(pluginName) {
+		return nil, false, fmt.Errorf("invalid plugin name %s/%s", category, pluginName)
+	}
+
+	config := pluginConfig(pluginName)
+	manifest, err := pm.readManifest(category, pluginName)
+	if err == nil {
+		err = checkManifest(category, pluginName, manifest, config)
+	}
+	if err != nil {
+		pm.MarkFailed(category, pluginName, err)
+		return nil, false, err
+	}
+	mode, pluginPath, err := pm.resolvePlugin(category, pluginName, config.GetString("mode"))
+	if err != nil {
+		pm.MarkFailed(category, pluginName, err)
+		return nil, false, err
+	}
+	hash, err := fileHash(pluginPath)
+	if err != nil {
+		err = fmt.Errorf("failed to open plugin %s: %w", pluginName, err)
+		pm.MarkFailed(category, pluginName, err)
+		return nil, false, err
 	}
 
-	// Construct the plugin file path
-	pluginPath := filepath.Join(pm.basePath, category, pluginName+".so")
+	if current, exists := pm.getLoaded(category, pluginName); exists && current.Hash == hash {
+		return current, false, nil
+	}
+
+	if mode == ModeProcess {
+		loaded, err = pm.startPlugin(category, pluginName, pluginPath, hash, config)
+	} else {
+		loaded, err = pm.openPlugin(category, pluginName, pluginPath, hash, config)
+	}
+	if err != nil {
+		pm.MarkFailed(category, pluginName, err)
+		return nil, false, err
+	}
+	loaded.Manifest = manifest
+	return loaded, true, nil
+}
+
+// validPluginName reports whether a name from a request or the config can be
+// used as a file name inside the plugins directory.
+func validPluginName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
+
+// resolvePlugin finds the plugin file for the requested mode.
+func (pm *PluginManager) resolvePlugin(category, pluginName, mode string) (string, string, error) {
+	soPath := filepath.Join(pm.basePath, category, pluginName+".so")
+	binPath := filepath.Join(pm.basePath, category, pluginName)
+
+	switch mode {
+	case ModeInProcess:
+		return ModeInProcess, soPath, nil
+	case ModeProcess:
+		return ModeProcess, binPath, nil
+	case "":
+		if _, err := os.Stat(soPath); err == nil {
+			return ModeInProcess, soPath, nil
+		}
+		if info, err := os.Stat(binPath); err == nil && !info.IsDir() {
+			return ModeProcess, binPath, nil
+		}
+		return "", "", fmt.Errorf("plugin %s not found in %s", pluginName, filepath.Join(pm.basePath, category))
+	}
+	return "", "", fmt.Errorf("plugin %s has unknown mode %q", pluginName, mode)
+}
+
+// startPlugin runs a versioned copy of a plugin executable as a supervised
+// child process and initializes it over its socket.
+func (pm *PluginManager) startPlugin(category, pluginName, pluginPath, hash string, config shared.PluginConfig) (*LoadedPlugin, error) {
+	versionPath, err := pm.versionedCopy(category, pluginName, pluginPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy plugin %s: %w", pluginName, err)
+	}
+
+	client := rpcplugin.NewClient(pluginName, versionPath)
+	if err := client.Init(config); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return &LoadedPlugin{
+		Category:  category,
+		Name:      pluginName,
+		Mode:      ModeProcess,
+		Path:      pluginPath,
+		Hash:      hash,
+		Instance:  client,
+		Lifecycle: client,
+		LoadedAt:  time.Now(),
+	}, nil
+}
+
+// openPlugin opens a versioned copy of the plugin file, looks up its exported
+// symbol and runs its initializer. Go caches plugins by path and cannot open
+// a changed file at the same path again, hence the copy named after the hash.
+// Panics raised by the plugin during Init are returned as errors.
+func (pm *PluginManager) openPlugin(category, pluginName, pluginPath, hash string, config shared.PluginConfig) (loaded *LoadedPlugin, err error) {
+	versionPath, err := pm.versionedCopy(category, pluginName, pluginPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy plugin %s: %w", pluginName, err)
+	}
 
 	// Open the plugin file
-	p, err := plugin.Open(pluginPath)
+	p, err := plugin.Open(versionPath)
 	if err != nil {
-		return fmt.Errorf("failed to open plugin %s: %w", pluginName, err)
+		return nil, fmt.Errorf("failed to open plugin %s: %w", pluginName, err)
 	}
 
 	// Lookup the exported symbol "Plugin"
 	symbol, err := p.Lookup("Plugin")
 	if err != nil {
-		return fmt.Errorf("failed to find symbol 'Plugin' in %s: %w", pluginName, err)
+		return nil, fmt.Errorf("failed to find symbol 'Plugin' in %s: %w", pluginName, err)
+	}
+
+	loaded = &LoadedPlugin{
+		Category: category,
+		Name:     pluginName,
+		Mode:     ModeInProcess,
+		Path:     pluginPath,
+		Hash:     hash,
+		Instance: symbol,
+		LoadedAt: time.Now(),
+	}
+
+	// Hand the plugin its config and let it validate it
+	if lifecycle, ok := symbol.(shared.PluginLifecycle); ok {
+		defer func() {
+			if r := recover(); r != nil {
+				loaded, err = nil, fmt.Errorf("plugin %s panicked during init: %v", pluginName, r)
+			}
+		}()
+		if err := lifecycle.Init(config); err != nil {
+			return nil, fmt.Errorf("plugin %s failed to initialize: %w", pluginName, err)
+		}
+		loaded.Lifecycle = lifecycle
+	}
+	return loaded, nil
+}
+
+func (pm *PluginManager) versionedCopy(category, pluginName, pluginPath, hash string) (string, error) {
+	dir := filepath.Join(pm.cacheDir, category)
+	versionPath := filepath.Join(dir, pluginName+"-"+hash[:16]+filepath.Ext(pluginPath))
+	if _, err := os.Stat(versionPath); err == nil {
+		return versionPath, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	src, err := os.Open(pluginPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	// Write under a temporary name so a half-written copy is never opened
+	tmp, err := os.CreateTemp(dir, pluginName+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
 	}
+	// Executables run in process mode must stay executable
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return "", err
+	}
+	return versionPath, os.Rename(tmp.Name(), versionPath)
+}
 
-	// Register the plugin under the category
-	pm.plugins[category][pluginName] = symbol
-	fmt.Printf("Plugin %s loaded successfully under category %s\n", pluginName, category)
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pluginConfig returns the [plugins.<name>] config sub-tree for a plugin
+func pluginConfig(pluginName string) shared.PluginConfig {
+	return shared.PluginConfig(cfg.GetAppConfig().GetStringMap("plugins." + pluginName))
+}
+
+// Register makes a staged plugin the registered instance for its category and
+// name. It returns the instance it replaced, which the caller should Retire
+// once no request uses it any more.
+func (pm *PluginManager) Register(loaded *LoadedPlugin) (replaced *LoadedPlugin) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if _, exists := pm.plugins[loaded.Category]; !exists {
+		pm.plugins[loaded.Category] = make(map[string]*LoadedPlugin)
+	}
+	replaced = pm.plugins[loaded.Category][loaded.Name]
+	pm.plugins[loaded.Category][loaded.Name] = loaded
+	if failures, exists := pm.failures[loaded.Category]; exists {
+		delete(failures, loaded.Name)
+	}
+	fmt.Printf("Plugin %s loaded successfully under category %s\n", loaded.Name, loaded.Category)
+	return replaced
+}
+
+// ReloadPlugin re-reads a registered plugin's file and, if it changed,
+// registers the new version and closes the old one.
+func (pm *PluginManager) ReloadPlugin(category, pluginName string) error {
+	if _, exists := pm.getLoaded(category, pluginName); !exists {
+		return fmt.Errorf("plugin %s/%s is not loaded", category, pluginName)
+	}
+
+	loaded, fresh, err := pm.StagePlugin(category, pluginName)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		return pm.Retire(pm.Register(loaded))
+	}
 	return nil
 }
 
+// MarkFailed records why a plugin could not be loaded or switched to.
+func (pm *PluginManager) MarkFailed(category, pluginName string, err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if _, exists := pm.failures[category]; !exists {
+		pm.failures[category] = make(map[string]error)
+	}
+	pm.failures[category][pluginName] = err
+}
+
+// Retire closes a plugin instance that has been replaced or unregistered.
+// Instances still registered (for example the same shared object loaded
+// again) are left open.
+func (pm *PluginManager) Retire(loaded *LoadedPlugin) (err error) {
+	if loaded == nil || loaded.Lifecycle == nil {
+		return nil
+	}
+
+	pm.mu.Lock()
+	for _, current := range pm.plugins[loaded.Category] {
+		if current.Instance == loaded.Instance {
+			pm.mu.Unlock()
+			return nil
+		}
+	}
+	pm.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin %s panicked during close: %v", loaded.Name, r)
+		}
+	}()
+	return loaded.Lifecycle.Close()
+}
+
 // GetPlugin retrieves a loaded plugin by category and name
 func (pm *PluginManager) GetPlugin(category, pluginName string) (interface{}, bool) {
+	loaded, exists := pm.getLoaded(category, pluginName)
+	if !exists {
+		return nil, false
+	}
+	return loaded.Instance, true
+}
+
+func (pm *PluginManager) getLoaded(category, pluginName string) (*LoadedPlugin, bool) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
@@ -67,8 +386,52 @@ func (pm *PluginManager) GetPlugin(category, pluginName string) (interface{}, bo
 		return nil, false
 	}
 
-	plugin, exists := pluginsInCategory[pluginName]
-	return plugin, exists
+	loaded, exists := pluginsInCategory[pluginName]
+	return loaded, exists
+}
+
+// HealthCheck probes a loaded plugin's backend.
+func (pm *PluginManager) HealthCheck(ctx context.Context, category, pluginName string) error {
+	loaded, exists := pm.getLoaded(category, pluginName)
+	if !exists {
+		return fmt.Errorf("plugin %s/%s is not loaded", category, pluginName)
+	}
+	return loaded.Health(ctx)
+}
+
+// ClosePlugin unregisters a plugin and releases its resources. The shared
+// object itself stays mapped, since Go cannot unload plugins; loading it again
+// re-runs Init.
+func (pm *PluginManager) ClosePlugin(category, pluginName string) error {
+	pm.mu.Lock()
+	loaded, exists := pm.plugins[category][pluginName]
+	if exists {
+		delete(pm.plugins[category], pluginName)
+	}
+	pm.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+	return pm.Retire(loaded)
+}
+
+// FailedPlugins lists plugins whose last load attempt failed, by category and
+// name, together with the error.
+func (pm *PluginManager) FailedPlugins() map[string]map[string]error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	result := make(map[string]map[string]error)
+	for category, failures := range pm.failures {
+		for pluginName, err := range failures {
+			if result[category] == nil {
+				result[category] = make(map[string]error)
+			}
+			result[category][pluginName] = err
+		}
+	}
+	return result
 }
 
 // ListPlugins lists all loaded plugins by category
@@ -84,4 +447,3 @@ func (pm *PluginManager) ListPlugins() map[string][]string {
 	}
 	return result
 }
-
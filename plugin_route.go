@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/qtopie/homa/gen/assistant"
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RPC kinds a route rule can match on
+const (
+	rpcChat         = "chat"
+	rpcAutoComplete = "autocomplete"
+)
+
+// pluginFailure records a failed plugin load, so the plugin is not retried
+// on every request.
+type pluginFailure struct {
+	at  time.Time
+	err error
+}
+
+// routeRule sends matching requests to a plugin other than the active one.
+// Rules are read from [route.<name>] sections and tried in name order; the
+// first rule whose criteria all match wins. Unset criteria match anything.
+type routeRule struct {
+	name      string
+	rpc       string // chat or autocomplete
+	workspace string // glob matched against the workspace or any parent directory
+	filename  string // glob matched against the file's path or base name
+	plugin    string
+	hints     []string // plugins a request may pick instead; "*" allows any
+	strict    bool     // never fall back; the default for workspace rules
+}
+
+// routeRules reads the routing rules from the config.
+func routeRules() []routeRule {
+	sections := cfg.GetAppConfig().GetStringMap("route")
+	rules := make([]routeRule, 0, len(sections))
+	for name, section := range sections {
+		values, ok := section.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conf := shared.PluginConfig(values)
+		rule := routeRule{
+			name:      name,
+			rpc:       conf.GetString("rpc"),
+			workspace: conf.GetString("workspace"),
+			filename:  conf.GetString("filename"),
+			plugin:    conf.GetString("plugin"),
+			hints:     pluginList(conf.GetString("hints")),
+		}
+		// A workspace rule keeps its requests on its plugin unless told otherwise
+		rule.strict = rule.workspace != ""
+		if strict, err := strconv.ParseBool(conf.GetString("strict")); err == nil {
+			rule.strict = strict
+		}
+		if rule.plugin == "" {
+			log.Printf("Ignoring route %s: no plugin set", name)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].name < rules[j].name })
+	return rules
+}
+
+func (r *routeRule) matches(rpc string, req *assistant.UserRequest) bool {
+	if r.rpc != "" && r.rpc != rpc {
+		return false
+	}
+	if r.workspace != "" && !matchWorkspace(r.workspace, req.Workspace) {
+		return false
+	}
+	if r.filename != "" && !matchGlob(r.filename, req.Filename) && !matchGlob(r.filename, filepath.Base(req.Filename)) {
+		return false
+	}
+	return true
+}
+
+// allows reports whether a request hint may pick the named plugin.
+func (r *routeRule) allows(name string) bool {
+	if name == r.plugin {
+		return true
+	}
+	for _, hint := range r.hints {
+		if hint == "*" || hint == name {
+			return true
+		}
+	}
+	return false
+}
+
+func matchWorkspace(pattern, workspace string) bool {
+	if workspace == "" {
+		return false
+	}
+	for dir := filepath.Clean(workspace); ; dir = filepath.Dir(dir) {
+		if matchGlob(pattern, dir) {
+			return true
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+func matchGlob(pattern, name string) bool {
+	if name == "" {
+		return false
+	}
+	matched, err := filepath.Match(pattern, name)
+	if err != nil {
+		log.Printf("Invalid route pattern %q: %v", pattern, err)
+	}
+	return matched
+}
+
+// routeHints lists the plugins a request matching no rule may pick, from
+// the hints key of the [route] section.
+func routeHints() []string {
+	return pluginList(cfg.GetAppConfig().GetString("route.hints"))
+}
+
+// routePlugin picks the plugin for a request: the first matching rule's
+// plugin, or the request's plugin hint if the rule allows it. Without a
+// matching rule the hint is only followed if [route] hints allows it. An
+// empty name means the active plugin. strict reports whether the request is
+// pinned to the plugin by a strict rule.
+func routePlugin(rpc string, req *assistant.UserRequest) (name string, strict bool) {
+	var match *routeRule
+	rules := routeRules()
+	for i := range rules {
+		if rules[i].matches(rpc, req) {
+			match = &rules[i]
+			break
+		}
+	}
+
+	if match == nil {
+		unrouted := routeRule{hints: routeHints()}
+		if req.Plugin != "" && unrouted.allows(req.Plugin) {
+			return req.Plugin, false
+		}
+		return "", false
+	}
+	if req.Plugin != "" && match.allows(req.Plugin) {
+		return req.Plugin, match.strict
+	}
+	return match.plugin, match.strict
+}
+
+// acquireNamed returns the named plugin, or the active one if name is empty.
+// Plugins other than the active one are loaded on first use and stay loaded
+// next to it. Callers must release the plugin when done.
+func (s *CopilotServiceServerImpl) acquireNamed(name string) (*activePlugin, error) {
+	if name == "" || name == s.selectedPlugin() {
+		return s.acquirePlugin()
+	}
+	if !validPluginName(name) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid plugin name %q", name)
+	}
+
+	s.mu.Lock()
+	routed := s.routed[name]
+	if routed == nil && s.active != nil && s.active.name == name {
+		routed = s.active
+	}
+	if routed != nil {
+		routed.inflight.Add(1)
+		s.mu.Unlock()
+		return routed, nil
+	}
+	s.mu.Unlock()
+
+	return s.loadRouted(name)
+}
+
+// loadRouted loads a plugin that requests are routed to and pins it for the
+// caller.
+func (s *CopilotServiceServerImpl) loadRouted(name string) (*activePlugin, error) {
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	s.mu.Lock()
+	if routed := s.routed[name]; routed != nil {
+		routed.inflight.Add(1)
+		s.mu.Unlock()
+		return routed, nil
+	}
+	failure, failed := s.routeFailures[name]
+	s.mu.Unlock()
+	if failed && time.Since(failure.at) < pluginRetryInterval {
+		return nil, status.Errorf(codes.Unavailable, "copilot plugin %s failed to load: %v", name, failure.err)
+	}
+
+	routed, replaced, err := s.stageRouted(name)
+	if err != nil {
+		log.Printf("Error loading routed copilot plugin %s: %v", name, err)
+		s.mu.Lock()
+		s.routeFailures[name] = pluginFailure{at: time.Now(), err: err}
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.Unavailable, "copilot plugin %s failed to load: %v", name, err)
+	}
+
+	s.mu.Lock()
+	delete(s.routeFailures, name)
+	s.routed[name] = routed
+	routed.inflight.Add(1)
+	s.mu.Unlock()
+	log.Printf("Copilot plugin %s is now serving routed requests", name)
+
+	s.retireUnused(replaced)
+	return routed, nil
+}
+
+// stageRouted loads the current version of a routed plugin and registers it.
+// It returns the registered instance it replaced, which the caller retires.
+func (s *CopilotServiceServerImpl) stageRouted(name string) (routed *activePlugin, replaced *LoadedPlugin, err error) {
+	loaded, fresh, err := s.pluginManager.StagePlugin("copilot", name)
+	if err != nil {
+		return nil, nil, err
+	}
+	copilotPlugin, ok := asCopilotPlugin(loaded.Instance)
+	if !ok {
+		if fresh {
+			_ = s.pluginManager.Retire(loaded)
+		}
+		return nil, nil, fmt.Errorf("plugin %s does not implement CopilotPlugin interface", name)
+	}
+	if fresh {
+		replaced = s.pluginManager.Register(loaded)
+	}
+	return &activePlugin{name: name, plugin: copilotPlugin, loaded: loaded}, replaced, nil
+}
+
+// retireUnused closes a replaced plugin instance unless the active plugin
+// still serves from it; the active plugin is retired by its own swap.
+func (s *CopilotServiceServerImpl) retireUnused(replaced *LoadedPlugin) {
+	if replaced == nil {
+		return
+	}
+	s.mu.Lock()
+	inUse := s.active != nil && s.active.loaded == replaced
+	s.mu.Unlock()
+	if !inUse {
+		go func() { _ = s.pluginManager.Retire(replaced) }()
+	}
+}
+
+// reloadRouted swaps a routed plugin for the new version of its file.
+func (s *CopilotServiceServerImpl) reloadRouted(name string) {
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	routed, replaced, err := s.stageRouted(name)
+	if err != nil {
+		log.Printf("Error reloading routed copilot plugin %s: %v", name, err)
+		return
+	}
+
+	s.mu.Lock()
+	old := s.routed[name]
+	if old != nil && old.loaded == routed.loaded {
+		s.mu.Unlock()
+		return
+	}
+	s.routed[name] = routed
+	s.mu.Unlock()
+	log.Printf("Reloaded routed copilot plugin %s", name)
+	s.completions.purge()
+
+	if old != nil {
+		go func() {
+			old.inflight.Wait()
+			s.retireUnused(old.loaded)
+		}()
+	}
+	if old == nil || replaced != old.loaded {
+		s.retireUnused(replaced)
+	}
+}
+
+// isRouted reports whether requests are currently routed to a plugin.
+func (s *CopilotServiceServerImpl) isRouted(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.routed[name]
+	return exists
+}
+
+// pruneRoutes unloads routed plugins that no rule or fallback chain refers to
+// any more. Plugins only reached through hints are loaded again on their next
+// request.
+func (s *CopilotServiceServerImpl) pruneRoutes() {
+	wanted := make(map[string]bool)
+	for _, rule := range routeRules() {
+		wanted[rule.plugin] = true
+	}
+	for _, rpc := range []string{rpcChat, rpcAutoComplete} {
+		for _, name := range fallbackPlugins(rpc) {
+			wanted[name] = true
+		}
+	}
+
+	s.mu.Lock()
+	var stale []*activePlugin
+	for name, routed := range s.routed {
+		if !wanted[name] {
+			stale = append(stale, routed)
+			delete(s.routed, name)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, routed := range stale {
+		go func() {
+			routed.inflight.Wait()
+			if routed.name == s.currentName() || s.isRouted(routed.name) {
+				return
+			}
+			if err := s.pluginManager.ClosePlugin("copilot", routed.name); err != nil {
+				log.Printf("Error closing copilot plugin %s: %v", routed.name, err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/qtopie/homa/gen/assistant"
+	cfg "github.com/qtopie/homa/internal/app/config"
+)
+
+// setConfig overrides a config key for the duration of a test.
+func setConfig(t *testing.T, key string, value any) {
+	t.Helper()
+	conf := cfg.GetAppConfig()
+	previous := conf.Get(key)
+	conf.Set(key, value)
+	t.Cleanup(func() { conf.Set(key, previous) })
+}
+
+func TestRoutePlugin(t *testing.T) {
+	setConfig(t, "route", map[string]any{
+		"hints": "mock",
+		"10-secret": map[string]any{
+			"workspace": "/work/secret",
+			"plugin":    "local",
+		},
+		"15-shared": map[string]any{
+			"workspace": "/work/shared",
+			"plugin":    "local",
+			"hints":     "mock, eino",
+			"strict":    "false",
+		},
+		"20-complete": map[string]any{
+			"rpc":    "autocomplete",
+			"plugin": "mock",
+			"hints":  "*",
+		},
+		"30-go": map[string]any{
+			"filename": "*.go",
+			"plugin":   "eino",
+		},
+	})
+
+	tests := []struct {
+		name       string
+		rpc        string
+		req        *assistant.UserRequest
+		wantPlugin string
+		wantStrict bool
+	}{
+		{
+			name: "no rule uses the active plugin",
+			rpc:  rpcChat,
+			req:  &assistant.UserRequest{Filename: "main.py"},
+		},
+		{
+			name: "no rule ignores a hint [route] does not allow",
+			rpc:  rpcChat,
+			req:  &assistant.UserRequest{Plugin: "gemini"},
+		},
+		{
+			name:       "no rule follows a hint [route] allows",
+			rpc:        rpcChat,
+			req:        &assistant.UserRequest{Plugin: "mock"},
+			wantPlugin: "mock",
+		},
+		{
+			name:       "workspace rule is strict by default",
+			rpc:        rpcChat,
+			req:        &assistant.UserRequest{Workspace: "/work/secret/app"},
+			wantPlugin: "local",
+			wantStrict: true,
+		},
+		{
+			name:       "workspace rule ignores hints it does not allow",
+			rpc:        rpcChat,
+			req:        &assistant.UserRequest{Workspace: "/work/secret", Plugin: "gemini"},
+			wantPlugin: "local",
+			wantStrict: true,
+		},
+		{
+			name:       "allowed hint is followed",
+			rpc:        rpcChat,
+			req:        &assistant.UserRequest{Workspace: "/work/shared", Plugin: "eino"},
+			wantPlugin: "eino",
+		},
+		{
+			name:       "disallowed hint falls back to the rule",
+			rpc:        rpcChat,
+			req:        &assistant.UserRequest{Workspace: "/work/shared", Plugin: "gemini"},
+			wantPlugin: "local",
+		},
+		{
+			name:       "wildcard hint allows any plugin",
+			rpc:        rpcAutoComplete,
+			req:        &assistant.UserRequest{Plugin: "gemini"},
+			wantPlugin: "gemini",
+		},
+		{
+			name:       "rpc rule wins over later rules",
+			rpc:        rpcAutoComplete,
+			req:        &assistant.UserRequest{Filename: "main.go"},
+			wantPlugin: "mock",
+		},
+		{
+			name:       "filename rule matches the base name",
+			rpc:        rpcChat,
+			req:        &assistant.UserRequest{Filename: "/src/cmd/main.go", Plugin: "gemini"},
+			wantPlugin: "eino",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, strict := routePlugin(tt.rpc, tt.req)
+			if plugin != tt.wantPlugin || strict != tt.wantStrict {
+				t.Errorf("routePlugin() = %q, %v; want %q, %v", plugin, strict, tt.wantPlugin, tt.wantStrict)
+			}
+		})
+	}
+}
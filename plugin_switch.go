@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// pluginRetryInterval is how long a plugin that failed to load is skipped
+	// before the server tries to switch to it again.
+	pluginRetryInterval = 30 * time.Second
+
+	// pluginHealthTimeout bounds the health probe run before a plugin swap.
+	pluginHealthTimeout = 10 * time.Second
+)
+
+// activePlugin is the copilot plugin currently serving requests. Requests pin
+// it with acquirePlugin so that, after a swap, the old plugin is only closed
+// once every request still using it has finished.
+type activePlugin struct {
+	name     string
+	plugin   CopilotPlugin
+	loaded   *LoadedPlugin
+	inflight sync.WaitGroup
+}
+
+func (a *activePlugin) release() {
+	a.inflight.Done()
+}
+
+// acquirePlugin returns the active copilot plugin, loading the configured
+// one first if none is active yet. Callers must release it when done.
+func (s *CopilotServiceServerImpl) acquirePlugin() (*activePlugin, error) {
+	if err := s.loadAndRefreshPlugin(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		return nil, status.Error(codes.Unavailable, "no copilot plugin loaded")
+	}
+	s.active.inflight.Add(1)
+	return s.active, nil
+}
+
+// loadAndRefreshPlugin checks the configured copilot plugin against the
+// active one. The first plugin is loaded synchronously; later switches run in
+// the background while requests keep being served by the current plugin.
+func (s *CopilotServiceServerImpl) loadAndRefreshPlugin() error {
+	copilotPluginName := s.selectedPlugin()
+	if copilotPluginName == "" {
+		return fmt.Errorf("no copilot plugin specified in configuration")
+	}
+
+	s.mu.Lock()
+	active := s.active
+	s.mu.Unlock()
+
+	if active == nil {
+		if err := s.switchPlugin(copilotPluginName, false); err != nil && s.currentName() == "" {
+			return status.Errorf(codes.Unavailable, "copilot plugin failed to load: %v", err)
+		}
+		return nil
+	}
+	if active.name != copilotPluginName {
+		s.switchPluginAsync(copilotPluginName, false)
+	}
+	return nil
+}
+
+// switchPluginAsync runs switchPlugin in the background unless a switch is
+// already in progress.
+func (s *CopilotServiceServerImpl) switchPluginAsync(name string, reload bool) {
+	if !s.switching.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer s.switching.Store(false)
+		if err := s.switchPlugin(name, reload); err != nil {
+			log.Printf("Copilot plugin switch to %s failed: %v", name, err)
+		}
+	}()
+}
+
+// selectedPlugin returns the copilot plugin that should be active: the one
+// activated through the admin service, or else the configured one.
+func (s *CopilotServiceServerImpl) selectedPlugin() string {
+	s.mu.Lock()
+	selected := s.selected
+	s.mu.Unlock()
+	if selected != "" {
+		return selected
+	}
+	return cfg.GetAppConfig().GetString("plugins.copilot")
+}
+
+// switchPlugin loads the named plugin and makes it the active one once it
+// has initialized and, if another plugin is serving, passed a health check.
+// With reload set, the plugin file is re-read even if the plugin is already
+// active, so an updated binary takes over, and a recent failure is retried.
+// On failure the current plugin keeps serving.
+func (s *CopilotServiceServerImpl) switchPlugin(name string, reload bool) error {
+	s.switchMu.Lock()
+	defer s.switchMu.Unlock()
+
+	s.mu.Lock()
+	active := s.active
+	throttled := s.failedName == name && time.Since(s.failedAt) < pluginRetryInterval
+	failedErr := s.failedErr
+	s.mu.Unlock()
+
+	if active != nil && active.name == name && !reload {
+		return nil
+	}
+	if throttled && !reload {
+		return failedErr
+	}
+
+	log.Printf("Loading copilot plugin: %s", name)
+	loaded, fresh, err := s.pluginManager.StagePlugin("copilot", name)
+	if err == nil && !fresh && active != nil && active.loaded == loaded {
+		// Same binary as the one already serving
+		return nil
+	}
+
+	var copilotPlugin CopilotPlugin
+	if err == nil {
+		// Assert the plugin to the CopilotPlugin interface, adapting v1 plugins
+		var ok bool
+		if copilotPlugin, ok = asCopilotPlugin(loaded.Instance); !ok {
+			err = fmt.Errorf("plugin %s does not implement CopilotPlugin interface", name)
+		}
+	}
+	if err == nil && active != nil {
+		// Only swap a working plugin for one that can reach its backend
+		ctx, cancel := context.WithTimeout(context.Background(), pluginHealthTimeout)
+		if herr := loaded.Health(ctx); herr != nil {
+			err = fmt.Errorf("copilot plugin %s failed health check: %w", name, herr)
+		}
+		cancel()
+	}
+	if err != nil {
+		if loaded != nil && fresh {
+			_ = s.pluginManager.Retire(loaded)
+		}
+		log.Printf("Error loading copilot plugin %s: %v", name, err)
+		s.pluginManager.MarkFailed("copilot", name, err)
+
+		s.mu.Lock()
+		s.failedName, s.failedAt, s.failedErr = name, time.Now(), err
+		s.mu.Unlock()
+		return err
+	}
+
+	var replaced *LoadedPlugin
+	if fresh {
+		replaced = s.pluginManager.Register(loaded)
+	}
+
+	// Update the active plugin; the old one drains in the background
+	s.mu.Lock()
+	s.active = &activePlugin{name: name, plugin: copilotPlugin, loaded: loaded}
+	s.failedName, s.failedErr = "", nil
+	s.mu.Unlock()
+	log.Printf("Copilot plugin %s is now active", name)
+
+	if active != nil {
+		// Completions of the previous version are not the new one's
+		s.completions.purge()
+		go s.retire(active)
+	}
+	if replaced != nil && (active == nil || replaced != active.loaded) {
+		go func() { _ = s.pluginManager.Retire(replaced) }()
+	}
+	return nil
+}
+
+// retire waits for in-flight requests on a replaced plugin, then closes it.
+func (s *CopilotServiceServerImpl) retire(old *activePlugin) {
+	old.inflight.Wait()
+	if old.name != s.currentName() {
+		if s.isRouted(old.name) {
+			// Still serving routed requests
+			return
+		}
+		// Switched to another plugin rather than a new version of this one
+		if err := s.pluginManager.ClosePlugin("copilot", old.name); err != nil {
+			log.Printf("Error closing copilot plugin %s: %v", old.name, err)
+		}
+		return
+	}
+	if err := s.pluginManager.Retire(old.loaded); err != nil {
+		log.Printf("Error closing copilot plugin %s: %v", old.name, err)
+	}
+}
+
+func (s *CopilotServiceServerImpl) currentName() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		return ""
+	}
+	return s.active.name
+}
+
+// ActivatePlugin switches to the named copilot plugin and keeps it active
+// regardless of plugins.copilot until the config file changes again.
+func (s *CopilotServiceServerImpl) ActivatePlugin(name string) error {
+	s.mu.Lock()
+	previous := s.selected
+	s.selected = name
+	s.mu.Unlock()
+
+	if err := s.switchPlugin(name, true); err != nil {
+		s.mu.Lock()
+		if s.selected == name {
+			s.selected = previous
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// ReloadPlugin re-reads the active copilot plugin's file and swaps in the new
+// version.
+func (s *CopilotServiceServerImpl) ReloadPlugin() error {
+	name := s.currentName()
+	if name == "" {
+		return fmt.Errorf("no copilot plugin is active")
+	}
+	return s.switchPlugin(name, true)
+}
+
+// OnConfigChange switches to the configured copilot plugin after the config
+// file changed. An edited config takes precedence over a plugin activated
+// through the admin service.
+func (s *CopilotServiceServerImpl) OnConfigChange() {
+	s.mu.Lock()
+	s.selected = ""
+	s.mu.Unlock()
+	s.pruneRoutes()
+	s.inflight.reload()
+
+	name := cfg.GetAppConfig().GetString("plugins.copilot")
+	if name != "" && name != s.currentName() {
+		s.switchPluginAsync(name, false)
+	}
+}
+
+// OnPluginFileChange reloads the active or a routed copilot plugin when its
+// binary has been replaced on disk.
+func (s *CopilotServiceServerImpl) OnPluginFileChange(category, pluginName string) {
+	if category != "copilot" {
+		return
+	}
+	if pluginName == s.currentName() {
+		s.switchPluginAsync(pluginName, true)
+	}
+	if s.isRouted(pluginName) {
+		go s.reloadRouted(pluginName)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pluginSettleDelay is how long a plugin file must stay unchanged before a
+// change is reported, so a binary that is still being written is not opened.
+const pluginSettleDelay = time.Second
+
+// WatchPlugins watches every category directory under the base path,
+// including ones created later, and calls onChange with the category and name
+// of each plugin file (.so or executable) that is created, rewritten or
+// replaced. The returned function stops the watcher.
+func (pm *PluginManager) WatchPlugins(onChange func(category, pluginName string)) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(pm.basePath); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(pm.basePath)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := watcher.Add(filepath.Join(pm.basePath, entry.Name())); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	go func() {
+		var mu sync.Mutex
+		timers := make(map[string]*time.Timer)
+
+		// settle reports a plugin file once it stopped changing
+		settle := func(path string) {
+			// Go plugins end in .so, process-mode plugins have no extension
+			base := filepath.Base(path)
+			if ext := filepath.Ext(base); ext != ".so" && ext != "" || strings.HasPrefix(base, ".") {
+				return
+			}
+			category := filepath.Base(filepath.Dir(path))
+			pluginName := strings.TrimSuffix(base, ".so")
+
+			// Restart the settle timer on every event for the same file
+			mu.Lock()
+			defer mu.Unlock()
+			if t, exists := timers[path]; exists {
+				t.Stop()
+			}
+			var t *time.Timer
+			t = time.AfterFunc(pluginSettleDelay, func() {
+				mu.Lock()
+				if timers[path] == t {
+					delete(timers, path)
+				}
+				mu.Unlock()
+				log.Printf("Plugin file changed: %s/%s", category, pluginName)
+				onChange(category, pluginName)
+			})
+			timers[path] = t
+		}
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				if filepath.Dir(event.Name) != filepath.Clean(pm.basePath) {
+					settle(event.Name)
+					continue
+				}
+
+				// A new category directory; plugins may already be in it
+				if info, err := os.Stat(event.Name); err != nil || !info.IsDir() || !event.Has(fsnotify.Create) {
+					continue
+				}
+				if err := watcher.Add(event.Name); err != nil {
+					log.Printf("Failed to watch plugin directory %s: %v", event.Name, err)
+					continue
+				}
+				files, _ := os.ReadDir(event.Name)
+				for _, file := range files {
+					if !file.IsDir() {
+						settle(filepath.Join(event.Name, file.Name()))
+					}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Plugin watcher error: %v", err)
+			}
+		}
+	}()
+
+	return watcher.Close, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchPluginsSeesNewCategories(t *testing.T) {
+	base := t.TempDir()
+	pm := NewPluginManager(base)
+	changed := make(chan string, 4)
+	stop, err := pm.WatchPlugins(func(category, pluginName string) {
+		changed <- category + "/" + pluginName
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	dir := filepath.Join(base, "copilot")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Give the watcher time to add the new directory before writing into it
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "mock.so"), []byte("plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-changed:
+		if got != "copilot/mock" {
+			t.Errorf("changed %s, want copilot/mock", got)
+		}
+	case <-time.After(pluginSettleDelay + 2*time.Second):
+		t.Fatal("no change reported for a plugin in a new category")
+	}
+	select {
+	case got := <-changed:
+		t.Errorf("change of %s reported twice", got)
+	case <-time.After(pluginSettleDelay + 200*time.Millisecond):
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/auth"
+	"github.com/qtopie/homa/internal/session"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loadHistory returns the history of a session bound with bindSession and
+// records the user's message.
+func (s *CopilotServiceServerImpl) loadHistory(ctx context.Context, sessionID, message string) (hist []shared.Message) {
+	store := s.sessionStore
+	if h, err := store.GetHistory(ctx, sessionID); err == nil {
+		hist = h
+	}
+	_ = store.AppendHistory(ctx, sessionID, shared.Message{Role: "user", Content: message, Time: time.Now().Unix()})
+	return hist
+}
+
+// bindSession binds a session to the caller and workspace on first use, and
+// fails with PermissionDenied for sessions bound to others. ok reports
+// whether the session can be used.
+func (s *CopilotServiceServerImpl) bindSession(ctx context.Context, sessionID, workspace string) (ok bool, err error) {
+	store := s.sessionStore
+	if store == nil || sessionID == "" {
+		return false, nil
+	}
+	if err := store.Bind(ctx, sessionID, auth.Name(ctx), workspace); err != nil {
+		if errors.Is(err, session.ErrNotOwner) {
+			return false, status.Errorf(codes.PermissionDenied, "session %s belongs to another user or workspace", sessionID)
+		}
+		log.Printf("failed to open session %s: %v", sessionID, err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// saveReply adds the assistant's reply to a session bound with bindSession.
+func (s *CopilotServiceServerImpl) saveReply(ctx context.Context, sessionID, reply string) {
+	_ = s.sessionStore.AppendHistory(ctx, sessionID, shared.Message{Role: "assistant", Content: reply, Time: time.Now().Unix()})
+}
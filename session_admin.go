@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/qtopie/homa/gen/assistant"
+	"github.com/qtopie/homa/internal/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SessionAdminServiceServerImpl implements the SessionAdminService on top of
+// the copilot service's session store.
+type SessionAdminServiceServerImpl struct {
+	assistant.UnimplementedSessionAdminServiceServer
+	copilot *CopilotServiceServerImpl
+}
+
+// NewSessionAdminServiceServerImpl creates a new instance of
+// SessionAdminServiceServerImpl
+func NewSessionAdminServiceServerImpl(copilot *CopilotServiceServerImpl) *SessionAdminServiceServerImpl {
+	return &SessionAdminServiceServerImpl{copilot: copilot}
+}
+
+// requireAdmin fails with PermissionDenied unless the caller is an admin.
+// Without authentication every caller is.
+func requireAdmin(ctx context.Context) error {
+	if !authEnabled() {
+		return nil
+	}
+	if p := auth.FromContext(ctx); p == nil || !p.Admin {
+		return status.Error(codes.PermissionDenied, "admin access required")
+	}
+	return nil
+}
+
+// ListSessions lists the sessions of a user, or of every user
+func (s *SessionAdminServiceServerImpl) ListSessions(ctx context.Context, req *assistant.ListSessionsRequest) (*assistant.ListSessionsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	store := s.copilot.sessionStore
+	if store == nil {
+		return nil, status.Error(codes.Unavailable, "the session store is not available")
+	}
+
+	sessions, err := store.List(ctx, auth.NormalizeName(req.Principal), req.AllPrincipals)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to list sessions: %v", err)
+	}
+	resp := &assistant.ListSessionsResponse{}
+	for _, info := range sessions {
+		if req.Workspace != "" && info.Workspace != req.Workspace {
+			continue
+		}
+		resp.Sessions = append(resp.Sessions, &assistant.SessionInfo{
+			SessionId: info.ID,
+			Principal: info.Owner,
+			Workspace: info.Workspace,
+			CreatedAt: info.Created,
+		})
+	}
+	sort.Slice(resp.Sessions, func(i, j int) bool {
+		a, b := resp.Sessions[i], resp.Sessions[j]
+		if a.Principal != b.Principal {
+			return a.Principal < b.Principal
+		}
+		return a.CreatedAt < b.CreatedAt
+	})
+	return resp, nil
+}
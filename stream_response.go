@@ -0,0 +1,121 @@
+package main
+
+import (
+	"github.com/qtopie/homa/gen/assistant"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// responseSink sends chunks as typed StreamResponse events, naming the plugin
+// in the response header before the first one. A chunk may carry several
+// events; they are sent as reasoning, text, tool, citations, then usage.
+type responseSink struct {
+	stream       grpc.ServerStreamingServer[assistant.StreamResponse]
+	req          shared.UserRequest
+	header       bool
+	finishReason shared.FinishReason
+}
+
+func (r *responseSink) send(plugin string, chunk shared.ChunkData) error {
+	r.setHeader(plugin)
+	if chunk.FinishReason != "" {
+		r.finishReason = chunk.FinishReason
+	}
+
+	var events []*assistant.StreamResponse
+	if chunk.Reasoning != "" {
+		events = append(events, &assistant.StreamResponse{Event: &assistant.StreamResponse_Reasoning{
+			Reasoning: &assistant.ReasoningDelta{Content: chunk.Reasoning},
+		}})
+	}
+	if chunk.Content != "" {
+		events = append(events, &assistant.StreamResponse{Content: chunk.Content, Event: &assistant.StreamResponse_Text{
+			Text: &assistant.TokenDelta{Content: chunk.Content},
+		}})
+	}
+	if tool := chunk.Tool; tool != nil {
+		if tool.Done {
+			events = append(events, &assistant.StreamResponse{Event: &assistant.StreamResponse_ToolResult{
+				ToolResult: &assistant.ToolCallResult{Call: toolCallMessage(tool.Call), Result: tool.Result, Denied: tool.Denied},
+			}})
+		} else {
+			events = append(events, &assistant.StreamResponse{Event: &assistant.StreamResponse_ToolCall{
+				ToolCall: &assistant.ToolCallStarted{Call: toolCallMessage(tool.Call)},
+			}})
+		}
+	}
+	for _, citation := range chunk.Citations {
+		events = append(events, &assistant.StreamResponse{Event: &assistant.StreamResponse_Citation{Citation: citationMessage(citation)}})
+	}
+	if chunk.Usage != nil {
+		events = append(events, &assistant.StreamResponse{Event: &assistant.StreamResponse_Usage{Usage: usageMessage(chunk.Usage)}})
+	}
+
+	for _, event := range events {
+		event.Id = chunk.ID
+		if err := r.sendEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish ends a completed reply of plugin with the final event.
+func (r *responseSink) finish(plugin string) error {
+	r.setHeader(plugin)
+	return r.sendEvent(&assistant.StreamResponse{Event: &assistant.StreamResponse_Final{
+		Final: &assistant.StreamFinal{FinishReason: finishReasonMessage(r.finishReason)},
+	}})
+}
+
+// fail ends a failed reply with an error event ahead of err's status, unless
+// the client has gone away.
+func (r *responseSink) fail(err error) {
+	if r.stream.Context().Err() != nil {
+		return
+	}
+	st := status.Convert(err)
+	_ = r.sendEvent(&assistant.StreamResponse{Event: &assistant.StreamResponse_Error{
+		Error: &assistant.StreamError{Code: st.Code().String(), Message: st.Message()},
+	}})
+}
+
+func (r *responseSink) sendEvent(event *assistant.StreamResponse) error {
+	event.SessionId = r.req.SessionId
+	event.Seq = r.req.Seq
+	return r.stream.Send(event)
+}
+
+// setHeader names the plugin answering, unless a chunk already did.
+func (r *responseSink) setHeader(plugin string) {
+	if !r.header {
+		_ = r.stream.SetHeader(metadata.Pairs(pluginHeader, plugin))
+		r.header = true
+	}
+}
+
+func finishReasonMessage(reason shared.FinishReason) assistant.FinishReason {
+	switch reason {
+	case shared.FinishStop:
+		return assistant.FinishReason_FINISH_REASON_STOP
+	case shared.FinishLength:
+		return assistant.FinishReason_FINISH_REASON_LENGTH
+	case shared.FinishContentFilter:
+		return assistant.FinishReason_FINISH_REASON_CONTENT_FILTER
+	}
+	return assistant.FinishReason_FINISH_REASON_UNSPECIFIED
+}
+
+func citationMessage(c shared.Citation) *assistant.Citation {
+	return &assistant.Citation{Uri: c.URI, Title: c.Title, StartIndex: int32(c.StartIndex), EndIndex: int32(c.EndIndex)}
+}
+
+func usageMessage(u *shared.Usage) *assistant.Usage {
+	return &assistant.Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qtopie/homa/gen/assistant"
+	cfg "github.com/qtopie/homa/internal/app/config"
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+	"github.com/qtopie/homa/internal/usage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// usageRecordTimeout bounds writing the usage of one plugin call, which
+// happens after the reply so that it does not hold up the client.
+const usageRecordTimeout = 5 * time.Second
+
+// usageMeter sums the usage of one plugin call: unary calls report it
+// through the context, streams in their chunks.
+type usageMeter struct {
+	mu    sync.Mutex
+	usage shared.Usage
+}
+
+// meterUsage returns a context whose reported usage is summed by the meter.
+func meterUsage(ctx context.Context) (context.Context, *usageMeter) {
+	m := &usageMeter{}
+	return shared.WithUsageReporter(ctx, m), m
+}
+
+func (m *usageMeter) ReportUsage(u shared.Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Add(u)
+}
+
+func (m *usageMeter) total() shared.Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+var _ shared.UsageReporter = (*usageMeter)(nil)
+
+// requestUsage gathers the usage of one RPC over its fallback attempts, so
+// that the RPC is accounted as a single request to the plugin tried last.
+// The tokens of every attempt count, including failed and cancelled ones,
+// since the upstream bills them all the same.
+type requestUsage struct {
+	scopes budgetScopes
+	plugin string
+	usage  shared.Usage
+}
+
+// attempt adds a call to plugin that reported u.
+func (r *requestUsage) attempt(plugin string, u shared.Usage) {
+	r.plugin = plugin
+	r.usage.Add(u)
+}
+
+// recordRequest accounts the RPC r gathered the usage of, unless it never
+// reached a plugin.
+func (s *CopilotServiceServerImpl) recordRequest(req shared.UserRequest, r *requestUsage) {
+	if r.plugin != "" {
+		s.recordUsage(r.plugin, req, r.scopes, r.usage)
+	}
+}
+
+// recordUsage accounts a request to plugin and its usage to req's session and
+// workspace, and charges it to the budgets of scopes.
+func (s *CopilotServiceServerImpl) recordUsage(plugin string, req shared.UserRequest, scopes budgetScopes, u shared.Usage) {
+	store, budgets := s.usageStore, s.budgetStore
+	if store == nil && budgets == nil {
+		return
+	}
+	now := time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), usageRecordTimeout)
+		defer cancel()
+		if store != nil {
+			if err := store.Add(ctx, now, req.Workspace, req.SessionId, plugin, u); err != nil {
+				log.Printf("failed to record usage of plugin %s: %v", plugin, err)
+			}
+		}
+		if budgets != nil {
+			chargeBudgets(ctx, budgets, now, scopes, u)
+		}
+	}()
+}
+
+// UsageServiceServerImpl implements the UsageService on top of the copilot
+// service's usage store.
+type UsageServiceServerImpl struct {
+	assistant.UnimplementedUsageServiceServer
+	copilot *CopilotServiceServerImpl
+}
+
+// NewUsageServiceServerImpl creates a new instance of UsageServiceServerImpl
+func NewUsageServiceServerImpl(copilot *CopilotServiceServerImpl) *UsageServiceServerImpl {
+	return &UsageServiceServerImpl{copilot: copilot}
+}
+
+// GetUsage sums the usage of the queried days
+func (s *UsageServiceServerImpl) GetUsage(ctx context.Context, req *assistant.UsageQuery) (*assistant.UsageReport, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	store := s.copilot.usageStore
+	if store == nil {
+		return nil, status.Error(codes.Unavailable, "usage accounting is not available")
+	}
+
+	to := req.To
+	if to == "" {
+		to = time.Now().UTC().Format(usage.DayLayout)
+	}
+	from := req.From
+	if from == "" {
+		from = to
+	}
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse(usage.DayLayout, day); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid day %q, want YYYY-MM-DD", day)
+		}
+	}
+	if from > to {
+		return nil, status.Errorf(codes.InvalidArgument, "from %s is after to %s", from, to)
+	}
+
+	records, err := store.List(ctx, from, to)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to read usage: %v", err)
+	}
+	return usageReport(records, req, loadPrices()), nil
+}
+
+// usageReport filters records by the query and sums them, in total and per
+// group.
+func usageReport(records []usage.Record, req *assistant.UsageQuery, prices priceTable) *assistant.UsageReport {
+	report := &assistant.UsageReport{Total: &assistant.UsageTotals{}, Currency: prices.currency}
+	rows := make(map[usage.Record]*assistant.UsageTotals)
+	for _, r := range records {
+		if (req.Workspace != "" && r.Workspace != req.Workspace) ||
+			(req.SessionId != "" && r.SessionID != req.SessionId) ||
+			(req.Plugin != "" && r.Plugin != req.Plugin) {
+			continue
+		}
+		cost := prices.cost(r.Plugin, r.Counters)
+		addTotals(report.Total, r.Counters, cost)
+		if len(req.GroupBy) == 0 {
+			continue
+		}
+
+		group := usage.Record{}
+		for _, g := range req.GroupBy {
+			switch g {
+			case assistant.UsageGroup_USAGE_GROUP_DAY:
+				group.Day = r.Day
+			case assistant.UsageGroup_USAGE_GROUP_WORKSPACE:
+				group.Workspace = r.Workspace
+			case assistant.UsageGroup_USAGE_GROUP_SESSION:
+				group.SessionID = r.SessionID
+			case assistant.UsageGroup_USAGE_GROUP_PLUGIN:
+				group.Plugin = r.Plugin
+			}
+		}
+		totals, ok := rows[group]
+		if !ok {
+			totals = &assistant.UsageTotals{}
+			rows[group] = totals
+		}
+		addTotals(totals, r.Counters, cost)
+	}
+
+	for group, totals := range rows {
+		report.Rows = append(report.Rows, &assistant.UsageRow{
+			Day:       group.Day,
+			Workspace: group.Workspace,
+			SessionId: group.SessionID,
+			Plugin:    group.Plugin,
+			Totals:    totals,
+		})
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Workspace != b.Workspace {
+			return a.Workspace < b.Workspace
+		}
+		if a.SessionId != b.SessionId {
+			return a.SessionId < b.SessionId
+		}
+		return a.Plugin < b.Plugin
+	})
+	return report
+}
+
+func addTotals(t *assistant.UsageTotals, c usage.Counters, cost float64) {
+	t.Requests += c.Requests
+	t.PromptTokens += c.PromptTokens
+	t.CompletionTokens += c.CompletionTokens
+	t.CachedTokens += c.CachedTokens
+	t.Cost += cost
+}
+
+// pluginPrice is what a plugin's tokens cost, per million tokens.
+type pluginPrice struct {
+	prompt     float64
+	completion float64
+	cached     float64
+}
+
+// priceTable holds the [pricing.<plugin>] sections of the config.
+type priceTable struct {
+	currency string
+	plugins  map[string]pluginPrice
+}
+
+// loadPrices reads the price table from the config; plugins without a
+// section cost nothing.
+func loadPrices() priceTable {
+	conf := cfg.GetAppConfig()
+	prices := priceTable{
+		currency: shared.PluginConfig(conf.GetStringMap("pricing")).GetString("currency"),
+		plugins:  make(map[string]pluginPrice),
+	}
+	if prices.currency == "" {
+		prices.currency = "USD"
+	}
+	for name, section := range conf.GetStringMap("pricing") {
+		if _, ok := section.(map[string]interface{}); !ok {
+			continue
+		}
+		p := shared.PluginConfig(conf.GetStringMap("pricing." + name))
+		price := pluginPrice{
+			prompt:     p.GetFloat("prompt", 0),
+			completion: p.GetFloat("completion", 0),
+		}
+		// Cached prompt tokens cost as much as the others unless priced
+		price.cached = p.GetFloat("cached", price.prompt)
+		prices.plugins[strings.ToLower(name)] = price
+	}
+	return prices
+}
+
+// cost estimates what the usage c of plugin costs.
+func (t priceTable) cost(plugin string, c usage.Counters) float64 {
+	price, ok := t.plugins[strings.ToLower(plugin)]
+	if !ok {
+		return 0
+	}
+	uncached := c.PromptTokens - c.CachedTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*price.prompt +
+		float64(c.CachedTokens)*price.cached +
+		float64(c.CompletionTokens)*price.completion) / 1e6
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/qtopie/homa/internal/assistant/plugins/copilot/shared"
+)
+
+func TestRequestUsageCountsEveryAttempt(t *testing.T) {
+	var acct requestUsage
+	// A failed attempt is billed for what it used before failing
+	acct.attempt("gemini", shared.Usage{PromptTokens: 100, CompletionTokens: 3})
+	acct.attempt("local", shared.Usage{PromptTokens: 40, CompletionTokens: 10})
+
+	if acct.plugin != "local" {
+		t.Errorf("plugin = %q, want the plugin tried last", acct.plugin)
+	}
+	if want := (shared.Usage{PromptTokens: 140, CompletionTokens: 13}); acct.usage != want {
+		t.Errorf("usage = %+v, want %+v", acct.usage, want)
+	}
+}